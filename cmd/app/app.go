@@ -25,6 +25,8 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/cert-manager/csi-lib/driver"
 	"github.com/cert-manager/csi-lib/manager"
@@ -34,23 +36,37 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/yaml"
 
 	"github.com/cert-manager/csi-driver/cmd/app/options"
 	"github.com/cert-manager/csi-driver/internal/version"
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+	"github.com/cert-manager/csi-driver/pkg/events"
 	"github.com/cert-manager/csi-driver/pkg/filestore"
+	"github.com/cert-manager/csi-driver/pkg/hsm"
 	"github.com/cert-manager/csi-driver/pkg/keygen"
+	"github.com/cert-manager/csi-driver/pkg/metrics"
+	"github.com/cert-manager/csi-driver/pkg/policy"
 	"github.com/cert-manager/csi-driver/pkg/readinessgate"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate/spec"
 	"github.com/cert-manager/csi-driver/pkg/requestgen"
+	"github.com/cert-manager/csi-driver/pkg/secrets"
 )
 
 const (
@@ -75,15 +91,27 @@ func NewCommand(ctx context.Context) *cobra.Command {
 			ctrl.SetLogger(log)
 
 			log.Info("Starting driver", "version", version.VersionInfo())
+			validationOpts := validation.Options{
+				// Secrets are read as the pod's service account, so may only
+				// be referenced by volumes when token requests are used.
+				SecretReferencesAllowed: opts.UseTokenRequest,
+				// Pods have no IPs until their volumes are mounted, so pods
+				// are only read if mounting doesn't wait for the
+				// certificate.
+				PodAttributesAllowed:            opts.ContinueOnNotReady,
+				AllowedVolumeReadinessGateTypes: opts.AllowedVolumeReadinessGateTypes,
+			}
+			clusterDefaults, err := loadDefaultAttributesFile(opts.DefaultAttributesFile, validationOpts)
+			if err != nil {
+				return err
+			}
+
 			store, err := storage.NewFilesystem(opts.Logr.WithName("storage"), opts.DataRoot)
 			if err != nil {
 				return fmt.Errorf("failed to setup filesystem: %w", err)
 			}
 			store.FSGroupVolumeAttributeKey = csiapi.FSGroupKey
 
-			keyGenerator := keygen.Generator{Store: store}
-			writer := filestore.Writer{Store: store}
-
 			var clientForMeta manager.ClientForMetadataFunc
 			if opts.UseTokenRequest {
 				clientForMeta = util.ClientForMetadataTokenRequestEmptyAud(opts.RestConfig)
@@ -93,37 +121,37 @@ func NewCommand(ctx context.Context) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if len(gates) > 0 && !opts.ContinueOnNotReady {
-				return fmt.Errorf("--pod-readiness-gate requires --continue-on-not-ready=true")
+			if err := spec.ValidateTypes(opts.AllowedVolumeReadinessGateTypes); err != nil {
+				return fmt.Errorf("invalid --allowed-volume-readiness-gate-types: %w", err)
+			}
+			gatesEnabled := len(gates) > 0 || len(opts.AllowedVolumeReadinessGateTypes) > 0
+			if gatesEnabled && !opts.ContinueOnNotReady {
+				return fmt.Errorf("--pod-readiness-gate and --allowed-volume-readiness-gate-types require --continue-on-not-ready=true")
 			}
-			if len(gates) > 0 {
+			if gatesEnabled {
 				if err := validateGateBackoff(opts); err != nil {
 					return err
 				}
 			}
+			if opts.ReadinessGateTimeout < 0 {
+				return fmt.Errorf("--readiness-gate-timeout must be >= 0, got %s", opts.ReadinessGateTimeout)
+			}
 
-			mngrlog := opts.Logr.WithName("manager")
-			mgrOpts := manager.Options{
-				Client:             opts.CMClient,
-				ClientForMetadata:  clientForMeta,
-				MetadataReader:     store,
-				Clock:              clock.RealClock{},
-				Log:                &mngrlog,
-				NodeID:             opts.NodeID,
-				GeneratePrivateKey: keyGenerator.KeyForMetadata,
-				GenerateRequest:    requestgen.RequestForMetadata,
-				SignRequest:        signRequest,
-				WriteKeypair:       writer.WriteKeypair,
+			k8sClient, err := kubernetes.NewForConfig(opts.RestConfig)
+			if err != nil {
+				return fmt.Errorf("failed to build kubernetes client: %w", err)
 			}
-			if len(gates) > 0 {
-				k8sClient, err := kubernetes.NewForConfig(opts.RestConfig)
-				if err != nil {
-					return fmt.Errorf("failed to build kubernetes client: %w", err)
-				}
 
+			// The pod is read by readiness gates and by attributes derived
+			// from the pod, e.g. csi.cert-manager.io/ip-sans-from-pod, which
+			// may only be used when the driver continues on not ready. Pods
+			// are otherwise never read, so aren't watched.
+			var podLister corev1listers.PodLister
+			if opts.ContinueOnNotReady {
 				// Scope the informer to pods on this node so cache memory is
 				// bounded to the local pod count. The driver runs as a
-				// DaemonSet, so a node-scoped informer is the right granularity.
+				// DaemonSet, so a node-scoped informer is the right
+				// granularity.
 				nodeSelector := fields.OneTermEqualSelector("spec.nodeName", opts.NodeID).String()
 				podInformerFactory := informers.NewSharedInformerFactoryWithOptions(
 					k8sClient,
@@ -132,18 +160,179 @@ func NewCommand(ctx context.Context) *cobra.Command {
 						o.FieldSelector = nodeSelector
 					}),
 				)
-				podLister := podInformerFactory.Core().V1().Pods().Lister()
+				podLister = podInformerFactory.Core().V1().Pods().Lister()
 
 				podInformerFactory.Start(ctx.Done())
 				if !cache.WaitForCacheSync(ctx.Done(), podInformerFactory.Core().V1().Pods().Informer().HasSynced) {
 					return fmt.Errorf("failed to sync pod informer cache")
 				}
 				log.Info("pod informer cache synced", "node", opts.NodeID)
+			}
 
-				mgrOpts.ReadyToRequest = readinessgate.NewReadyToRequestFunc(podLister, gates)
+			// Volumes which are not ready within the timeout are either
+			// issued a certificate without the pod IPs they were waiting for,
+			// or given up on, rather than waiting indefinitely.
+			var gateTimeout *readinessgate.Timeout
+			if opts.ReadinessGateTimeout > 0 {
+				gateTimeout, err = readinessgate.NewTimeout(store, clock.RealClock{},
+					opts.ReadinessGateTimeout, readinessgate.TimeoutPolicy(opts.ReadinessGateTimeoutPolicy))
+				if err != nil {
+					return fmt.Errorf("invalid --readiness-gate-timeout-policy: %w", err)
+				}
+			}
+
+			// Passwords may be read from Secrets in the namespace of the pod
+			// owning each volume with token requests. They are read as the
+			// pod's service account, so are restricted by its RBAC rather
+			// than the driver's, which may not read Secrets.
+			var secretGetter *secrets.Getter
+			if opts.UseTokenRequest {
+				secretGetter = &secrets.Getter{
+					ClientForAttributes: secrets.ClientForAttributesTokenRequestEmptyAud(opts.RestConfig),
+				}
+			}
+			keyGenerator := keygen.Generator{
+				Store:           store,
+				Secrets:         secretGetter,
+				ClusterDefaults: clusterDefaults,
+				Validation:      validationOpts,
+			}
+			// Pregenerate keys which are slow to generate, e.g. RSA 4096, so
+			// that pods don't wait for them during large rollouts.
+			if len(opts.KeyPoolSizes) > 0 {
+				keyGenerator.Pool, err = keygen.NewPool(opts.Logr.WithName("key-pool"), opts.KeyPoolSizes)
+				if err != nil {
+					return fmt.Errorf("invalid --key-pool-size: %w", err)
+				}
+				if err := keyGenerator.Pool.Register(ctrlmetrics.Registry); err != nil {
+					return fmt.Errorf("failed to register key pool metrics: %w", err)
+				}
+			}
+			writer := filestore.Writer{
+				Store:           store,
+				Secrets:         secretGetter,
+				ClusterDefaults: clusterDefaults,
+				Validation:      validationOpts,
+			}
+
+			// Volumes with the pkcs11 key backend generate non-exportable
+			// keys in the token.
+			if len(opts.PKCS11Module) > 0 {
+				pin, err := os.ReadFile(opts.PKCS11PINFile)
+				if err != nil {
+					return fmt.Errorf("failed to read --pkcs11-pin-file: %w", err)
+				}
+				hsmBackend, err := hsm.New(opts.Logr.WithName("hsm"), hsm.Config{
+					ModulePath: opts.PKCS11Module,
+					TokenLabel: opts.PKCS11TokenLabel,
+					PIN:        strings.TrimSpace(string(pin)),
+					NodeID:     opts.NodeID,
+				})
+				if err != nil {
+					return err
+				}
+				defer hsmBackend.Close()
+				keyGenerator.HSM = hsmBackend
+				writer.HSM = hsmBackend
+			}
+
+			requestGenerator := &requestgen.Generator{
+				PodLister:       podLister,
+				NodeName:        opts.NodeID,
+				ClusterDefaults: clusterDefaults,
+				Validation:      validationOpts,
+			}
+			if gateTimeout != nil {
+				// Volumes which time out are issued without the pod IPs they
+				// were waiting for, but not until any other attributes read
+				// from the pod, e.g. the POD_IP variable, can be resolved.
+				requestGenerator.IssueWithoutPodIPs = gateTimeout.IssueWithoutPodIPs
+				gateTimeout.Issuable = requestGenerator.ReadyToRequest
+			}
+			var policyNamespace, policyName string
+			switch {
+			case len(opts.IssuancePolicyFile) > 0 && len(opts.IssuancePolicyConfigMap) > 0:
+				return fmt.Errorf("--issuance-policy-file and --issuance-policy-configmap are mutually exclusive")
+			case len(opts.IssuancePolicyFile) > 0:
+				requestGenerator.Policy = policy.NewEngine()
+				if err := requestGenerator.Policy.LoadFile(opts.IssuancePolicyFile); err != nil {
+					return err
+				}
+			case len(opts.IssuancePolicyConfigMap) > 0:
+				var ok bool
+				policyNamespace, policyName, ok = strings.Cut(opts.IssuancePolicyConfigMap, "/")
+				if !ok || len(policyNamespace) == 0 || len(policyName) == 0 {
+					return fmt.Errorf("--issuance-policy-configmap must be of the form <namespace>/<name>, got %q", opts.IssuancePolicyConfigMap)
+				}
+				// Requests are rejected until the ConfigMap has been loaded,
+				// so that they are never created unrestricted.
+				requestGenerator.Policy = policy.NewEngine()
+			}
+
+			mngrlog := opts.Logr.WithName("manager")
+			mgrOpts := manager.Options{
+				Client:             opts.CMClient,
+				ClientForMetadata:  clientForMeta,
+				MetadataReader:     store,
+				Clock:              clock.RealClock{},
+				Log:                &mngrlog,
+				NodeID:             opts.NodeID,
+				GeneratePrivateKey: keyGenerator.KeyForMetadata,
+				GenerateRequest:    requestGenerator.RequestForMetadata,
+				SignRequest:        signRequest,
+				WriteKeypair:       writer.WriteKeypair,
+			}
+
+			// Volumes with attributes derived from the pod are not ready until
+			// the pod has them, e.g. until it has been assigned IPs. Volumes
+			// requesting readiness gates which aren't allowed are never ready,
+			// so the reason is surfaced rather than the gates being ignored.
+			mgrOpts.ReadyToRequest = readinessgate.All(
+				readinessgate.NewReadyToRequestFunc(podLister, gates, opts.AllowedVolumeReadinessGateTypes),
+				requestGenerator.ReadyToRequest,
+			)
+			if gatesEnabled {
 				mgrOpts.GateBackoffConfig = gateBackoffConfigFromFlags(cmd.Flags(), opts)
 			}
 
+			if len(policyName) > 0 {
+				if err := requestGenerator.Policy.WatchConfigMap(ctx, opts.Logr.WithName("policy"), k8sClient, policyNamespace, policyName); err != nil {
+					return err
+				}
+			}
+
+			// Post Events on the Pod owning each volume, so that issuance
+			// failures and readiness gate waits are visible to application
+			// teams via `kubectl describe pod`. The correlator aggregates and
+			// rate limits Events, so renewal loops don't flood the API server.
+			eventBroadcaster := record.NewBroadcaster(
+				record.WithContext(ctx),
+				record.WithCorrelatorOptions(events.CorrelatorOptions()),
+			)
+			eventBroadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+			eventRecorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: opts.DriverName, Host: opts.NodeID})
+			driverEvents := events.New(eventRecorder, opts.CMClient, store)
+			driverEvents.Instrument(&mgrOpts)
+
+			// Record issuance attempts, successes, failures and latency, and
+			// expose per-volume certificate expiry gauges, alongside the
+			// controller-runtime metrics served by the metrics server.
+			driverMetrics := metrics.New(store, clock.RealClock{}, clusterDefaults)
+			if err := driverMetrics.Register(ctrlmetrics.Registry); err != nil {
+				return fmt.Errorf("failed to register driver metrics: %w", err)
+			}
+			driverMetrics.Instrument(&mgrOpts)
+
+			// The timeout wraps the instrumented ReadyToRequest, so that
+			// volumes which have timed out are no longer reported as waiting.
+			if gateTimeout != nil {
+				gateTimeout.OnTimeout = func(meta metadata.Metadata, reason string) {
+					driverEvents.ReadinessGatesTimedOut(meta, gateTimeout.Duration(), gateTimeout.Policy(), reason)
+					driverMetrics.ReadinessGatesTimedOut(meta, gateTimeout.Policy())
+				}
+				mgrOpts.ReadyToRequest = gateTimeout.ReadyToRequest(mgrOpts.ReadyToRequest)
+			}
+
 			d, err := driver.New(ctx, opts.Endpoint, opts.Logr.WithName("driver"), driver.Options{
 				DriverName:         opts.DriverName,
 				DriverVersion:      version.AppVersion,
@@ -164,6 +353,22 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				return nil
 			})
 
+			if keyGenerator.HSM != nil {
+				g.Go(func() error {
+					log.Info("running PKCS#11 key garbage collection")
+					keyGenerator.HSM.Run(gCTX, store)
+					return nil
+				})
+			}
+
+			if keyGenerator.Pool != nil {
+				g.Go(func() error {
+					log.Info("running key pool")
+					keyGenerator.Pool.Run(gCTX)
+					return nil
+				})
+			}
+
 			g.Go(func() error {
 				log.Info("running driver")
 				if err := d.Run(); err != nil {
@@ -182,6 +387,8 @@ func NewCommand(ctx context.Context) *cobra.Command {
 			//   (not updated by csi-driver because it doesn't use controller-runtime)
 			// * Leader election metrics
 			//   (not updated by csi-driver because it doesn't use leader-election)
+			// * csi-driver's own certificate issuance and expiry metrics
+			//   (see pkg/metrics)
 			//
 			// The full list is here:
 			// https://github.com/kubernetes-sigs/controller-runtime/blob/700befecdffa803d19830a6a43adc5779ed01e26/pkg/internal/controller/metrics/metrics.go#L73-L86
@@ -223,9 +430,37 @@ func NewCommand(ctx context.Context) *cobra.Command {
 
 	opts = opts.Prepare(cmd)
 
+	cmd.AddCommand(newValidateCommand())
+	cmd.AddCommand(newWebhookCommand(ctx))
+
 	return cmd
 }
 
+// loadDefaultAttributesFile reads the cluster-wide default volume attributes
+// from the given YAML file, and validates them with the given options. An
+// empty path returns no defaults.
+func loadDefaultAttributesFile(path string, opts validation.Options) (map[string]string, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --default-attributes-file: %w", err)
+	}
+
+	var attrs map[string]string
+	if err := yaml.UnmarshalStrict(data, &attrs); err != nil {
+		return nil, fmt.Errorf("failed to parse --default-attributes-file %q: %w", path, err)
+	}
+
+	if el := validation.ValidateDefaultAttributes(attrs, opts); len(el) > 0 {
+		return nil, fmt.Errorf("invalid --default-attributes-file %q: %w", path, el.ToAggregate())
+	}
+
+	return attrs, nil
+}
+
 // signRequest will sign an X.509 certificate signing request with the provided
 // private key.
 func signRequest(_ metadata.Metadata, key crypto.PrivateKey, request *x509.CertificateRequest) ([]byte, error) {
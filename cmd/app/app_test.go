@@ -18,6 +18,8 @@ package app
 
 import (
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/cert-manager/csi-driver/cmd/app/options"
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
 )
 
 // newGateBackoffFlagSet registers just the four --gate-backoff-* flags
@@ -207,3 +210,55 @@ func TestGateBackoffConfigFromFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadDefaultAttributesFile(t *testing.T) {
+	tests := map[string]struct {
+		contents string
+		wantErr  string
+	}{
+		"valid defaults": {
+			contents: `
+csi.cert-manager.io/issuer-name: internal-ca
+csi.cert-manager.io/issuer-kind: ClusterIssuer
+csi.cert-manager.io/key-algorithm: ECDSA
+csi.cert-manager.io/key-encoding: PKCS8
+`,
+		},
+		"empty file": {
+			contents: "",
+		},
+		"not a map of strings": {
+			contents: "csi.cert-manager.io/key-usages: [digital signature]\n",
+			wantErr:  "failed to parse --default-attributes-file",
+		},
+		"unknown attribute prefix": {
+			contents: "csi.storage.k8s.io/pod.name: my-pod\n",
+			wantErr:  "only csi.cert-manager.io/ attributes may be defaulted",
+		},
+		"invalid attribute value": {
+			contents: "csi.cert-manager.io/key-algorithm: ECDSA\ncsi.cert-manager.io/key-size: \"2048\"\n",
+			wantErr:  `volumeAttributes.csi.cert-manager.io/key-size: Unsupported value: "2048"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "defaults.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.contents), 0600))
+
+			_, err := loadDefaultAttributesFile(path, validation.Options{})
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.wantErr)
+			}
+		})
+	}
+
+	attrs, err := loadDefaultAttributesFile("", validation.Options{})
+	assert.NoError(t, err)
+	assert.Nil(t, attrs, "an empty path should return no defaults")
+
+	_, err = loadDefaultAttributesFile(filepath.Join(t.TempDir(), "missing.yaml"), validation.Options{})
+	assert.ErrorContains(t, err, "failed to read --default-attributes-file")
+}
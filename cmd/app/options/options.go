@@ -31,6 +31,8 @@ import (
 	"k8s.io/klog/v2"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"github.com/cert-manager/csi-driver/pkg/feature"
 )
 
 // Options are the main options for the driver. Populated via processing
@@ -73,10 +75,66 @@ type Options struct {
 	//   pod-ip:<family>               family: any | ipv4 | ipv6
 	//   pod-condition:<Type>[=<Status>]  Status defaults to "True"
 	//   pod-annotation:<key>          annotation key must be present
+	//   pod-cel:<expression>          CEL expression over the pod must be true
+	//   container-started:<name>      container, or sidecar, has started
+	//   container-ready:<name>        container, or sidecar, is ready
+	//   init-containers-complete      init containers have completed
+	// Specs may be prefixed with a group name, "<group>=<type>:<value>"; a
+	// group passes when any of its gates passes.
 	// Must be used together with --continue-on-not-ready=true; without it the
 	// driver will still block NodePublishVolume while waiting for the gates.
 	PodReadinessGates []string
 
+	// AllowedVolumeReadinessGateTypes are the readiness gate types which
+	// volumes may use in the csi.cert-manager.io/readiness-gates attribute,
+	// in addition to PodReadinessGates. If empty, volumes may not use any.
+	AllowedVolumeReadinessGateTypes []string
+
+	// ReadinessGateTimeout is how long a volume waits to be ready before its
+	// first certificate is requested, measured from when the volume was
+	// published, i.e. first checked for readiness. If zero, volumes wait
+	// indefinitely.
+	ReadinessGateTimeout time.Duration
+
+	// ReadinessGateTimeoutPolicy is what happens to volumes which are not
+	// ready within ReadinessGateTimeout: "issue" requests the certificate
+	// anyway, without the pod IPs not yet assigned, and "fail" gives up on
+	// the volume.
+	ReadinessGateTimeoutPolicy string
+
+	// DefaultAttributesFile is the path to a YAML map of volume attribute key
+	// to value, which is layered under every volume's attributes before the
+	// built-in defaults are applied.
+	DefaultAttributesFile string
+
+	// IssuancePolicyFile is the path to the issuance policy which is checked
+	// against every request before it is created.
+	IssuancePolicyFile string
+
+	// IssuancePolicyConfigMap is the <namespace>/<name> of a ConfigMap
+	// holding the issuance policy, which is watched for changes. Mutually
+	// exclusive with IssuancePolicyFile.
+	IssuancePolicyConfigMap string
+
+	// KeyPoolSizes is the number of private keys to pregenerate in the
+	// background for each key algorithm and size, keyed by
+	// "<algorithm>/<size>", e.g. "RSA/4096", or "<algorithm>" for algorithms
+	// without a size.
+	KeyPoolSizes map[string]int
+
+	// PKCS11Module is the path to the PKCS#11 module used by volumes with the
+	// "pkcs11" key backend. If empty, volumes may not use the backend. The
+	// backend requires a driver built with cgo, which the released images
+	// are not.
+	PKCS11Module string
+
+	// PKCS11TokenLabel is the label of the PKCS#11 token to generate keys in.
+	PKCS11TokenLabel string
+
+	// PKCS11PINFile is the path to a file containing the user PIN of the
+	// PKCS#11 token.
+	PKCS11PINFile string
+
 	// Logr is the shared base logger.
 	Logr logr.Logger
 
@@ -160,6 +218,12 @@ func (o *Options) addFlags(cmd *cobra.Command) {
 	o.kubeConfigFlags = genericclioptions.NewConfigFlags(true)
 	o.kubeConfigFlags.AddFlags(nfs.FlagSet("Kubernetes"))
 
+	addNamedFlagSets(cmd, nfs)
+}
+
+// addNamedFlagSets adds the given named flag sets to the command, and prints
+// them in sections in the command's usage and help output.
+func addNamedFlagSets(cmd *cobra.Command, nfs cliflag.NamedFlagSets) {
 	usageFmt := "Usage:\n  %s\n"
 	cmd.SetUsageFunc(func(cmd *cobra.Command) error {
 		fmt.Fprintf(cmd.OutOrStderr(), usageFmt, cmd.UseLine())
@@ -178,6 +242,21 @@ func (o *Options) addFlags(cmd *cobra.Command) {
 	}
 }
 
+// addDefaultAttributesFileFlag adds the --default-attributes-file flag,
+// shared by every subcommand which defaults volume attributes.
+func addDefaultAttributesFileFlag(fs *pflag.FlagSet, p *string) {
+	fs.StringVar(p, "default-attributes-file", "",
+		"Path to a YAML file containing a map of csi.cert-manager.io volume attribute keys to values. "+
+			"These are used as defaults for every volume, where the volume doesn't set the attribute itself, "+
+			"before the built-in defaults are applied. The file is validated at startup.")
+}
+
+// addFeatureGatesFlag adds the --feature-gates flag, shared by every
+// subcommand which validates volume attributes.
+func addFeatureGatesFlag(fs *pflag.FlagSet) {
+	feature.DefaultMutableFeatureGate.AddFlag(fs)
+}
+
 func (o *Options) addAppFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&o.logLevel,
 		"log-level", "v", "1",
@@ -222,7 +301,56 @@ func (o *Options) addAppFlags(fs *pflag.FlagSet) {
 			"  pod-ip:<family>                    family: any | ipv4 | ipv6\n"+
 			"  pod-condition:<Type>[=<Status>]    Status defaults to True\n"+
 			"  pod-annotation:<key>               annotation key must be present\n"+
+			"  pod-cel:<expression>               CEL expression over the pod, referred to as object, must be true\n"+
+			"  container-started:<name>           container, or sidecar, has started\n"+
+			"  container-ready:<name>             container, or sidecar, is ready\n"+
+			"  init-containers-complete           init containers have completed, and sidecars have started\n"+
+			"Prefix gates with a group name, <group>=<type>:<value>, to require any one gate of the group to pass, "+
+			"e.g. ip=pod-ip:ipv6 and ip=pod-ip:ipv4. "+
+			"Must be combined with --continue-on-not-ready=true to avoid blocking NodePublishVolume.")
+	fs.StringSliceVar(&o.AllowedVolumeReadinessGateTypes, "allowed-volume-readiness-gate-types", nil,
+		"The readiness gate types, e.g. pod-annotation, which volumes may use in the csi.cert-manager.io/readiness-gates attribute "+
+			"to defer their own certificate issuance, in addition to --pod-readiness-gate. "+
+			"Volumes using any other type are never issued a certificate. If empty, volumes may not use readiness gates. "+
 			"Must be combined with --continue-on-not-ready=true to avoid blocking NodePublishVolume.")
+	fs.DurationVar(&o.ReadinessGateTimeout, "readiness-gate-timeout", 0,
+		"How long a volume waits for its readiness gates, and for the pod IPs requested by csi.cert-manager.io/ip-sans-from-pod, "+
+			"before its first certificate is requested, measured from when the volume was published and first checked for readiness. "+
+			"The publish time is persisted in the volume's metadata, so the timeout survives driver restarts. "+
+			"Once timed out, the volume is handled according to --readiness-gate-timeout-policy. "+
+			"If 0, volumes wait indefinitely.")
+	fs.StringVar(&o.ReadinessGateTimeoutPolicy, "readiness-gate-timeout-policy", "fail",
+		`What happens to volumes which time out waiting for their readiness gates. "issue" requests the certificate anyway, `+
+			`without the csi.cert-manager.io/ip-sans-from-pod IPs the pod has not yet been assigned, `+
+			`once any variables read from the pod, e.g. POD_IP, can be expanded. `+
+			`"fail" gives up on the volume, which is never issued a certificate. Both post a Warning Event on the pod `+
+			"and increment the certmanager_csi_driver_readiness_gate_timeouts_total metric.")
+
+	addDefaultAttributesFileFlag(fs, &o.DefaultAttributesFile)
+	addFeatureGatesFlag(fs)
+
+	fs.StringVar(&o.IssuancePolicyFile, "issuance-policy-file", "",
+		"Path to a YAML issuance policy, which limits per namespace the issuers, duration, is-ca and "+
+			"DNS/URI SANs that volumes may request. Requests which violate the policy are never created.")
+	fs.StringVar(&o.IssuancePolicyConfigMap, "issuance-policy-configmap", "",
+		"The <namespace>/<name> of a ConfigMap holding the issuance policy under the key \"policy.yaml\". "+
+			"The ConfigMap is watched, and the policy reloaded when it changes. Mutually exclusive with --issuance-policy-file.")
+
+	fs.StringToIntVar(&o.KeyPoolSizes, "key-pool-size", nil,
+		"The number of private keys to pregenerate in the background for each key algorithm and size, "+
+			`given as "<algorithm>/<size>=<count>" pairs, e.g. "RSA/4096=8,ECDSA/256=16". `+
+			"Volumes requesting a pooled algorithm and size take a key from the pool instead of generating one, "+
+			"unless the pool is empty.")
+
+	fs.StringVar(&o.PKCS11Module, "pkcs11-module", "",
+		`Path to the PKCS#11 module used to generate the private keys of volumes with the "pkcs11" key backend, `+
+			"which are non-exportable and sign requests in the token. Requires the driver to be built with cgo, "+
+			"which the released images are not: a custom image must be built with CGO_ENABLED=1 on a base image "+
+			"providing a C library and the module. If empty, volumes may not use the pkcs11 key backend.")
+	fs.StringVar(&o.PKCS11TokenLabel, "pkcs11-token-label", "",
+		"Label of the PKCS#11 token to generate private keys in.")
+	fs.StringVar(&o.PKCS11PINFile, "pkcs11-pin-file", "",
+		"Path to a file containing the user PIN of the PKCS#11 token.")
 
 	// Gate-pending backoff: applied between readiness-gate checks while the gate
 	// is not yet met. Distinct from the renewal backoff used for issuance errors,
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	cliflag "k8s.io/component-base/cli/flag"
+)
+
+// ValidateOptions are the options for the validate subcommand. Populated via
+// processing command line flags.
+type ValidateOptions struct {
+	// Filename is the path to the manifest to validate. The value "-" reads
+	// the manifest from stdin.
+	Filename string
+
+	// DriverName is the name of the CSI driver whose volumes are validated.
+	// Volumes using any other driver are ignored.
+	DriverName string
+
+	// DefaultAttributesFile is the path to the cluster-wide default volume
+	// attributes, as given to the driver.
+	DefaultAttributesFile string
+
+	// UseTokenRequest is whether the driver uses the empty audience token
+	// request, as given to the driver.
+	UseTokenRequest bool
+
+	// ContinueOnNotReady is whether the driver continues mounting volumes
+	// which are not ready, as given to the driver.
+	ContinueOnNotReady bool
+
+	// AllowedVolumeReadinessGateTypes are the readiness gate types which
+	// volumes may use, as given to the driver.
+	AllowedVolumeReadinessGateTypes []string
+}
+
+func NewValidate() *ValidateOptions {
+	return new(ValidateOptions)
+}
+
+func (o *ValidateOptions) Prepare(cmd *cobra.Command) *ValidateOptions {
+	var nfs cliflag.NamedFlagSets
+	o.addFlags(nfs.FlagSet("Validate"))
+	addNamedFlagSets(cmd, nfs)
+	return o
+}
+
+func (o *ValidateOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Filename, "filename", "f", "-",
+		`Path to a Pod or workload manifest to validate. Multiple YAML documents are supported. The value "-" reads the manifest from stdin.`)
+
+	fs.StringVar(&o.DriverName, "driver-name", "csi.cert-manager.io",
+		"The name of the CSI driver whose volumes are validated. Volumes using any other driver are ignored.")
+
+	addDefaultAttributesFileFlag(fs, &o.DefaultAttributesFile)
+	addFeatureGatesFlag(fs)
+
+	fs.BoolVar(&o.UseTokenRequest, "use-token-request", false,
+		"Whether the driver uses the empty audience token request, as given to the driver. "+
+			"Volumes may only reference Secrets with the password Secret attributes if it does.")
+	fs.BoolVar(&o.ContinueOnNotReady, "continue-on-not-ready", false,
+		"Whether the driver continues mounting volumes which are not ready, as given to the driver. "+
+			"Volumes may only use attributes read from the pod, csi.cert-manager.io/ip-sans-from-pod and the POD_IP, POD_IPS, POD_LABEL_<key> and POD_ANNOTATION_<key> variables, if it does.")
+	fs.StringSliceVar(&o.AllowedVolumeReadinessGateTypes, "allowed-volume-readiness-gate-types", nil,
+		"The readiness gate types which volumes may use in the csi.cert-manager.io/readiness-gates attribute, as given to the driver. "+
+			"Volumes using any other type fail validation.")
+}
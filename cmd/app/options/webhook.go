@@ -0,0 +1,127 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+)
+
+// WebhookOptions are the options for the webhook subcommand. Populated via
+// processing command line flags.
+type WebhookOptions struct {
+	// logLevel is the verbosity level the webhook will write logs at.
+	logLevel string
+
+	// DriverName is the name of the CSI driver whose volumes are validated.
+	// Volumes using any other driver are ignored.
+	DriverName string
+
+	// DefaultAttributesFile is the path to the cluster-wide default volume
+	// attributes, as given to the driver.
+	DefaultAttributesFile string
+
+	// UseTokenRequest is whether the driver uses the empty audience token
+	// request, as given to the driver.
+	UseTokenRequest bool
+
+	// ContinueOnNotReady is whether the driver continues mounting volumes
+	// which are not ready, as given to the driver.
+	ContinueOnNotReady bool
+
+	// AllowedVolumeReadinessGateTypes are the readiness gate types which
+	// volumes may use, as given to the driver.
+	AllowedVolumeReadinessGateTypes []string
+
+	// Port is the port the webhook server listens on.
+	Port int
+
+	// CertDir is the directory containing the webhook server's serving
+	// certificate and private key.
+	CertDir string
+
+	// CertName is the name of the serving certificate file in CertDir.
+	CertName string
+
+	// KeyName is the name of the serving private key file in CertDir.
+	KeyName string
+
+	// Logr is the shared base logger.
+	Logr logr.Logger
+}
+
+func NewWebhook() *WebhookOptions {
+	return new(WebhookOptions)
+}
+
+func (o *WebhookOptions) Prepare(cmd *cobra.Command) *WebhookOptions {
+	var nfs cliflag.NamedFlagSets
+	o.addFlags(nfs.FlagSet("Webhook"))
+	addNamedFlagSets(cmd, nfs)
+	return o
+}
+
+func (o *WebhookOptions) Complete() error {
+	klog.InitFlags(nil)
+	log := klog.TODO()
+	if err := flag.Set("v", o.logLevel); err != nil {
+		return fmt.Errorf("failed to set log level: %s", err)
+	}
+	o.Logr = log
+
+	return nil
+}
+
+func (o *WebhookOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.logLevel,
+		"log-level", "v", "1",
+		"Log level (1-5).")
+
+	fs.StringVar(&o.DriverName, "driver-name", "csi.cert-manager.io",
+		"The name of the CSI driver whose volumes are validated. Volumes using any other driver are ignored.")
+
+	addDefaultAttributesFileFlag(fs, &o.DefaultAttributesFile)
+	addFeatureGatesFlag(fs)
+
+	fs.BoolVar(&o.UseTokenRequest, "use-token-request", false,
+		"Whether the driver uses the empty audience token request, as given to the driver. "+
+			"Volumes may only reference Secrets with the password Secret attributes if it does.")
+	fs.BoolVar(&o.ContinueOnNotReady, "continue-on-not-ready", false,
+		"Whether the driver continues mounting volumes which are not ready, as given to the driver. "+
+			"Volumes may only use attributes read from the pod, csi.cert-manager.io/ip-sans-from-pod and the POD_IP, POD_IPS, POD_LABEL_<key> and POD_ANNOTATION_<key> variables, if it does.")
+	fs.StringSliceVar(&o.AllowedVolumeReadinessGateTypes, "allowed-volume-readiness-gate-types", nil,
+		"The readiness gate types which volumes may use in the csi.cert-manager.io/readiness-gates attribute, as given to the driver. "+
+			"Volumes using any other type fail validation.")
+
+	fs.IntVar(&o.Port, "port", 9443,
+		"The port the webhook server listens on.")
+
+	fs.StringVar(&o.CertDir, "tls-cert-dir", "/var/run/secrets/cert-manager-csi-driver/webhook",
+		"The directory containing the webhook server's serving certificate and private key.")
+
+	fs.StringVar(&o.CertName, "tls-cert-name", "tls.crt",
+		"The name of the serving certificate file in --tls-cert-dir.")
+
+	fs.StringVar(&o.KeyName, "tls-key-name", "tls.key",
+		"The name of the serving private key file in --tls-cert-dir.")
+}
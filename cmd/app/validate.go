@@ -0,0 +1,404 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/cert-manager/csi-driver/cmd/app/options"
+	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate/spec"
+	"github.com/cert-manager/csi-driver/pkg/requestgen"
+)
+
+const (
+	validateHelpOutput = "Validate the csi.cert-manager.io volume attributes of a Pod or workload manifest, without a cluster"
+
+	// placeholderPodUID is used as the pod UID when expanding volume
+	// attributes, since manifests don't have one until they are created.
+	placeholderPodUID = "00000000-0000-0000-0000-000000000000"
+
+	// placeholderPodIP and placeholderNodeName are used when expanding the
+	// POD_IP, POD_IPS and NODE_NAME variables, since they are only known once
+	// the pod is scheduled and running.
+	placeholderPodIP    = "192.0.2.1"
+	placeholderNodeName = "node-name"
+)
+
+// errValidationFailed is returned when one or more volumes fail validation,
+// after their errors have been printed.
+var errValidationFailed = errors.New("one or more volumes failed validation")
+
+// newValidateCommand returns the validate subcommand, which runs the same
+// defaulting, validation and request generation as the driver against the
+// CSI volumes of a manifest, so that attribute mistakes are caught before
+// deploying rather than leaving pods stuck in ContainerCreating.
+func newValidateCommand() *cobra.Command {
+	opts := options.NewValidate()
+
+	cmd := &cobra.Command{
+		Use:           "validate",
+		Short:         validateHelpOutput,
+		Long:          validateHelpOutput,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := spec.ValidateTypes(opts.AllowedVolumeReadinessGateTypes); err != nil {
+				return fmt.Errorf("invalid --allowed-volume-readiness-gate-types: %w", err)
+			}
+			validationOpts := validation.Options{
+				SecretReferencesAllowed:         opts.UseTokenRequest,
+				PodAttributesAllowed:            opts.ContinueOnNotReady,
+				AllowedVolumeReadinessGateTypes: opts.AllowedVolumeReadinessGateTypes,
+			}
+			clusterDefaults, err := loadDefaultAttributesFile(opts.DefaultAttributesFile, validationOpts)
+			if err != nil {
+				return err
+			}
+
+			in := cmd.InOrStdin()
+			if opts.Filename != "-" {
+				f, err := os.Open(opts.Filename)
+				if err != nil {
+					return fmt.Errorf("failed to open manifest: %w", err)
+				}
+				defer f.Close()
+				in = f
+			}
+
+			return validateManifest(cmd.OutOrStdout(), in, opts.DriverName, clusterDefaults, validationOpts)
+		},
+	}
+
+	opts.Prepare(cmd)
+
+	return cmd
+}
+
+// validateManifest validates every CSI volume using the given driver name in
+// the manifest read from in, defaulted with the given cluster-wide defaults
+// and validated with the given options, and prints the resulting request or
+// errors for each volume to out.
+func validateManifest(out io.Writer, in io.Reader, driverName string, clusterDefaults map[string]string, opts validation.Options) error {
+	reader := yaml.NewYAMLReader(bufio.NewReader(in))
+	decoder := scheme.Codecs.UniversalDeserializer()
+
+	var volumes, failed int
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, gvk, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to decode manifest: %w", err)
+		}
+
+		objMeta, podMeta, podSpec, ok := podTemplateForObject(obj)
+		if !ok {
+			fmt.Fprintf(out, "%s/%s: skipping, not a Pod or workload\n", gvk.Kind, objMeta.Name)
+			continue
+		}
+
+		for _, volume := range podSpec.Volumes {
+			if volume.CSI == nil || volume.CSI.Driver != driverName {
+				continue
+			}
+			volumes++
+
+			fmt.Fprintf(out, "%s/%s volume %q:\n", gvk.Kind, objMeta.Name, volume.Name)
+			meta := placeholderMetadata(objMeta, podMeta, podSpec, volume)
+			if !validateVolume(out, meta, placeholderGenerator(meta, podMeta, clusterDefaults, opts)) {
+				failed++
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "%d volume(s) validated, %d failed\n", volumes, failed)
+
+	if failed > 0 {
+		return errValidationFailed
+	}
+
+	return nil
+}
+
+// podTemplateForObject returns the object's metadata, along with the pod
+// metadata and spec of the Pod or workload pod template.
+func podTemplateForObject(obj runtime.Object) (metav1.ObjectMeta, metav1.ObjectMeta, *corev1.PodSpec, bool) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return o.ObjectMeta, o.ObjectMeta, &o.Spec, true
+	case *corev1.PodTemplate:
+		return o.ObjectMeta, o.Template.ObjectMeta, &o.Template.Spec, true
+	case *corev1.ReplicationController:
+		if o.Spec.Template == nil {
+			return o.ObjectMeta, metav1.ObjectMeta{}, nil, false
+		}
+		return o.ObjectMeta, o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.Deployment:
+		return o.ObjectMeta, o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.StatefulSet:
+		return o.ObjectMeta, o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.DaemonSet:
+		return o.ObjectMeta, o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.ReplicaSet:
+		return o.ObjectMeta, o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *batchv1.Job:
+		return o.ObjectMeta, o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *batchv1.CronJob:
+		return o.ObjectMeta, o.Spec.JobTemplate.Spec.Template.ObjectMeta, &o.Spec.JobTemplate.Spec.Template.Spec, true
+	default:
+		var objMeta metav1.ObjectMeta
+		if accessor, err := apimeta.Accessor(obj); err == nil {
+			objMeta.Name = accessor.GetName()
+			objMeta.Namespace = accessor.GetNamespace()
+		}
+		return objMeta, metav1.ObjectMeta{}, nil, false
+	}
+}
+
+// placeholderMetadata returns the volume metadata the Kubelet would pass to
+// the driver for the given volume, using placeholder values for the pod
+// fields which are only known once the pod is created.
+func placeholderMetadata(objMeta, podMeta metav1.ObjectMeta, podSpec *corev1.PodSpec, volume corev1.Volume) metadata.Metadata {
+	podName := podMeta.Name
+	if podName == "" {
+		podName = objMeta.Name
+	}
+	namespace := podMeta.Namespace
+	if namespace == "" {
+		namespace = objMeta.Namespace
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	serviceAccountName := podSpec.ServiceAccountName
+	if serviceAccountName == "" {
+		serviceAccountName = "default"
+	}
+
+	volumeContext := make(map[string]string, len(volume.CSI.VolumeAttributes)+4)
+	for k, v := range volume.CSI.VolumeAttributes {
+		volumeContext[k] = v
+	}
+	volumeContext[csiapi.K8sVolumeContextKeyPodName] = podName
+	volumeContext[csiapi.K8sVolumeContextKeyPodNamespace] = namespace
+	volumeContext[csiapi.K8sVolumeContextKeyPodUID] = placeholderPodUID
+	volumeContext[csiapi.K8sVolumeContextKeyServiceAccountName] = serviceAccountName
+
+	return metadata.Metadata{
+		VolumeID:      "csi-" + volume.Name,
+		VolumeContext: volumeContext,
+	}
+}
+
+// placeholderGenerator returns a request generator which reads the labels
+// and annotations of the manifest's pod, and placeholder values for the pod
+// fields which are only known once the pod is running. Volumes are defaulted
+// with the given cluster-wide defaults, and validated with the given options.
+func placeholderGenerator(meta metadata.Metadata, podMeta metav1.ObjectMeta, clusterDefaults map[string]string, opts validation.Options) *requestgen.Generator {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        meta.VolumeContext[csiapi.K8sVolumeContextKeyPodName],
+			Namespace:   meta.VolumeContext[csiapi.K8sVolumeContextKeyPodNamespace],
+			Labels:      podMeta.Labels,
+			Annotations: podMeta.Annotations,
+		},
+		Status: corev1.PodStatus{
+			PodIPs: []corev1.PodIP{{IP: placeholderPodIP}},
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+	// Adding to an indexer only fails if the key can't be computed, which
+	// can't happen for a pod.
+	_ = indexer.Add(pod)
+
+	return &requestgen.Generator{
+		PodLister:       corev1listers.NewPodLister(indexer),
+		NodeName:        placeholderNodeName,
+		ClusterDefaults: clusterDefaults,
+		Validation:      opts,
+	}
+}
+
+// validateVolume runs defaulting, validation and request generation for the
+// volume with the generator's configuration, printing either the resulting
+// request or the errors. Returns false if the volume is invalid.
+func validateVolume(out io.Writer, meta metadata.Metadata, generator *requestgen.Generator) bool {
+	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext, generator.ClusterDefaults)
+	if err != nil {
+		fmt.Fprintf(out, "  error: %v\n", err)
+		return false
+	}
+
+	if el := validation.ValidateAttributes(attrs, generator.Validation); len(el) > 0 {
+		for _, e := range el {
+			fmt.Fprintf(out, "  error: %v\n", e)
+		}
+		return false
+	}
+
+	// IP SANs read from the pod are only known once the pod is running, so
+	// generate the request without them.
+	podIPSources := attrs[csiapi.IPSANsFromPodKey]
+	if len(podIPSources) > 0 {
+		volumeContext := make(map[string]string, len(meta.VolumeContext))
+		for k, v := range meta.VolumeContext {
+			volumeContext[k] = v
+		}
+		delete(volumeContext, csiapi.IPSANsFromPodKey)
+		meta.VolumeContext = volumeContext
+	}
+
+	bundle, err := generator.RequestForMetadata(meta)
+	if err != nil {
+		fmt.Fprintf(out, "  error: %v\n", err)
+		return false
+	}
+
+	fmt.Fprintf(out, "  issuer: %s.%s/%s\n", bundle.IssuerRef.Kind, bundle.IssuerRef.Group, bundle.IssuerRef.Name)
+	fmt.Fprintf(out, "  subject: %s\n", subjectString(bundle.Request))
+	printList(out, "dns names", bundle.Request.DNSNames)
+	printList(out, "ip addresses", stringsOf(bundle.Request.IPAddresses))
+	if len(podIPSources) > 0 {
+		fmt.Fprintf(out, "  ip addresses from pod: %s (resolved at issuance)\n", podIPSources)
+	}
+	printList(out, "uris", stringsOf(bundle.Request.URIs))
+	printList(out, "usages", stringsOf(bundle.Usages))
+	fmt.Fprintf(out, "  duration: %s\n", bundle.Duration)
+	fmt.Fprintf(out, "  is ca: %t\n", bundle.IsCA)
+	fmt.Fprintf(out, "  private key: %s\n", strings.Join(nonEmpty(
+		attrs[csiapi.KeyAlgorithmKey], attrs[csiapi.KeySizeKey], attrs[csiapi.KeyEncodingKey],
+	), " "))
+	printList(out, "files", filesForAttributes(attrs))
+
+	return true
+}
+
+// filesForAttributes returns the names of the files which will be written to
+// the volume, according to the defaulted volume attributes.
+func filesForAttributes(attrs map[string]string) []string {
+	keyFile := attrs[csiapi.KeyFileKey]
+	if attrs[csiapi.KeyBackendKey] == csiapi.KeyBackendPKCS11 {
+		keyFile = attrs[csiapi.KeyURIFileKey]
+	}
+	files := []string{
+		attrs[csiapi.CertFileKey],
+		keyFile,
+		attrs[csiapi.CAFileKey],
+	}
+	for _, key := range []string{
+		csiapi.CertDERFileKey, csiapi.KeyDERFileKey, csiapi.CADERFileKey,
+		csiapi.LeafCertFileKey, csiapi.IntermediatesFileKey, csiapi.FullChainFileKey,
+		csiapi.CombinedPEMFileKey,
+	} {
+		if file := attrs[key]; len(file) > 0 {
+			files = append(files, file)
+		}
+	}
+	if attrs[csiapi.KeyStorePKCS12EnableKey] == "true" {
+		files = append(files, attrs[csiapi.KeyStorePKCS12FileKey])
+	}
+	if attrs[csiapi.TrustStorePKCS12EnableKey] == "true" {
+		files = append(files, attrs[csiapi.TrustStorePKCS12FileKey])
+	}
+	if attrs[csiapi.KeyStoreJKSEnableKey] == "true" {
+		files = append(files, attrs[csiapi.KeyStoreJKSFileKey])
+	}
+	if attrs[csiapi.TrustStoreJKSEnableKey] == "true" {
+		files = append(files, attrs[csiapi.TrustStoreJKSFileKey])
+	}
+	return files
+}
+
+// subjectString returns the subject of the request in RFC 2253 form, for
+// both literal and structured subjects.
+func subjectString(request *x509.CertificateRequest) string {
+	if len(request.RawSubject) == 0 {
+		return request.Subject.String()
+	}
+
+	var rdnSequence pkix.RDNSequence
+	if _, err := asn1.Unmarshal(request.RawSubject, &rdnSequence); err != nil {
+		return fmt.Sprintf("<invalid literal subject: %v>", err)
+	}
+	return rdnSequence.String()
+}
+
+func printList(out io.Writer, name string, list []string) {
+	if len(list) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "  %s:\n", name)
+	for _, s := range list {
+		fmt.Fprintf(out, "  - %s\n", s)
+	}
+}
+
+func stringsOf[T any](in []T) []string {
+	out := make([]string, 0, len(in))
+	for _, v := range in {
+		out = append(out, fmt.Sprint(v))
+	}
+	return out
+}
+
+func nonEmpty(in ...string) []string {
+	var out []string
+	for _, s := range in {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,388 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateManifest(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		manifest string
+
+		expErr    bool
+		expOutput []string
+	}{
+		"a valid Pod should print the request and files": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+  namespace: sandbox
+spec:
+  serviceAccountName: my-sa
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      readOnly: true
+      volumeAttributes:
+        csi.cert-manager.io/issuer-name: ca-issuer
+        csi.cert-manager.io/dns-names: ${POD_NAME}.${POD_NAMESPACE}.svc.cluster.local
+        csi.cert-manager.io/common-name: ${SERVICE_ACCOUNT_NAME}
+`,
+			expOutput: []string{
+				`Pod/my-pod volume "tls":`,
+				"  issuer: Issuer.cert-manager.io/ca-issuer",
+				"  subject: CN=my-sa",
+				"  - my-pod.sandbox.svc.cluster.local",
+				"  duration: 2160h0m0s",
+				"  private key: RSA 2048 PKCS1",
+				"  is ca: false",
+				"  - tls.crt",
+				"  - tls.key",
+				"  - ca.crt",
+				"1 volume(s) validated, 0 failed",
+			},
+		},
+		"a Deployment should use the pod template and default namespace": {
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: busybox
+      volumes:
+      - name: tls
+        csi:
+          driver: csi.cert-manager.io
+          volumeAttributes:
+            csi.cert-manager.io/issuer-name: ca-issuer
+            csi.cert-manager.io/issuer-kind: ClusterIssuer
+            csi.cert-manager.io/dns-names: ${POD_NAMESPACE}.example.com
+            csi.cert-manager.io/pkcs12-enable: "true"
+            csi.cert-manager.io/pkcs12-password: password
+`,
+			expOutput: []string{
+				`Deployment/my-app volume "tls":`,
+				"  issuer: ClusterIssuer.cert-manager.io/ca-issuer",
+				"  - default.example.com",
+				"  - keystore.p12",
+				"1 volume(s) validated, 0 failed",
+			},
+		},
+		"IP SANs from the pod should be noted but not resolved": {
+			args: []string{"--continue-on-not-ready"},
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      volumeAttributes:
+        csi.cert-manager.io/issuer-name: ca-issuer
+        csi.cert-manager.io/ip-sans-from-pod: pod-ips,network:macvlan
+`,
+			expOutput: []string{
+				"  ip addresses from pod: pod-ips,network:macvlan (resolved at issuance)",
+				"1 volume(s) validated, 0 failed",
+			},
+		},
+		"IP SANs from the pod should error unless the driver continues on not ready": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      volumeAttributes:
+        csi.cert-manager.io/issuer-name: ca-issuer
+        csi.cert-manager.io/ip-sans-from-pod: pod-ips
+`,
+			expErr: true,
+			expOutput: []string{
+				"volumeAttributes.csi.cert-manager.io/ip-sans-from-pod: Forbidden: attributes read from the pod may only be used when the driver is run with --continue-on-not-ready",
+				"1 volume(s) validated, 1 failed",
+			},
+		},
+		"pod variables should be expanded from the pod template and placeholders": {
+			args: []string{"--continue-on-not-ready"},
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  namespace: sandbox
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: busybox
+      volumes:
+      - name: tls
+        csi:
+          driver: csi.cert-manager.io
+          volumeAttributes:
+            csi.cert-manager.io/issuer-name: ca-issuer
+            csi.cert-manager.io/common-name: ${NODE_NAME}
+            csi.cert-manager.io/ip-sans: ${POD_IP}
+            csi.cert-manager.io/uri-sans: spiffe://td/ns/$POD_NAMESPACE/app/$POD_LABEL_app
+`,
+			expOutput: []string{
+				"  subject: CN=node-name",
+				"  - 192.0.2.1",
+				"  - spiffe://td/ns/sandbox/app/my-app",
+				"1 volume(s) validated, 0 failed",
+			},
+		},
+		"a label missing from the pod template should fail": {
+			args: []string{"--continue-on-not-ready"},
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      volumeAttributes:
+        csi.cert-manager.io/issuer-name: ca-issuer
+        csi.cert-manager.io/common-name: ${POD_LABEL_app}
+`,
+			expErr: true,
+			expOutput: []string{
+				`pod has no label "app" for the POD_LABEL_app variable`,
+				"1 volume(s) validated, 1 failed",
+			},
+		},
+		"readiness gates should error unless their types are allowed": {
+			args: []string{"--continue-on-not-ready", "--allowed-volume-readiness-gate-types=pod-annotation"},
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      volumeAttributes:
+        csi.cert-manager.io/issuer-name: ca-issuer
+        csi.cert-manager.io/readiness-gates: pod-annotation:example.com/ready;pod-ip:ipv6
+`,
+			expErr: true,
+			expOutput: []string{
+				`readiness gate "pod-ip:ipv6" is not allowed: volumes may only use the types [pod-annotation]`,
+				"1 volume(s) validated, 1 failed",
+			},
+		},
+		"unknown allowed readiness gate types should error": {
+			args:   []string{"--allowed-volume-readiness-gate-types=pod-label"},
+			expErr: true,
+		},
+		"volumes of other drivers should be ignored": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: other
+    csi:
+      driver: other.csi.example.com
+`,
+			expOutput: []string{
+				"0 volume(s) validated, 0 failed",
+			},
+		},
+		"invalid attributes should print field errors and fail": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      volumeAttributes:
+        csi.cert-manager.io/is-ca: "maybe"
+`,
+			expErr: true,
+			expOutput: []string{
+				`Pod/my-pod volume "tls":`,
+				"volumeAttributes.csi.cert-manager.io/issuer-name: Required value",
+				"volumeAttributes.csi.cert-manager.io/is-ca: Invalid value",
+				"1 volume(s) validated, 1 failed",
+			},
+		},
+		"an unknown expansion variable should fail": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      volumeAttributes:
+        csi.cert-manager.io/issuer-name: ca-issuer
+        csi.cert-manager.io/dns-names: ${NODE}.example.com
+`,
+			expErr: true,
+			expOutput: []string{
+				`undefined variable "NODE"`,
+				"1 volume(s) validated, 1 failed",
+			},
+		},
+		"multiple documents should all be validated": {
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod-a
+spec:
+  containers:
+  - name: app
+    image: busybox
+  volumes:
+  - name: tls
+    csi:
+      driver: csi.cert-manager.io
+      volumeAttributes:
+        csi.cert-manager.io/issuer-name: ca-issuer
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: my-job
+spec:
+  schedule: "* * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+          - name: app
+            image: busybox
+          volumes:
+          - name: tls
+            csi:
+              driver: csi.cert-manager.io
+              volumeAttributes:
+                csi.cert-manager.io/issuer-name: ca-issuer
+`,
+			expOutput: []string{
+				"ConfigMap/my-config: skipping, not a Pod or workload",
+				`Pod/pod-a volume "tls":`,
+				`CronJob/my-job volume "tls":`,
+				"2 volume(s) validated, 0 failed",
+			},
+		},
+		"a document which is not a Kubernetes object should error": {
+			manifest: "foo: bar\n",
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := newValidateCommand()
+			cmd.SetArgs(test.args)
+			cmd.SetIn(strings.NewReader(test.manifest))
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+
+			err := cmd.Execute()
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+
+			for _, line := range test.expOutput {
+				assert.Contains(t, out.String(), line)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/cert-manager/csi-driver/cmd/app/options"
+	"github.com/cert-manager/csi-driver/internal/version"
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate/spec"
+	"github.com/cert-manager/csi-driver/pkg/webhook"
+)
+
+const (
+	webhookHelpOutput = "Validating admission webhook which rejects Pods with invalid csi.cert-manager.io volume attributes (experimental)"
+
+	webhookLongHelpOutput = webhookHelpOutput + `
+
+The webhook is experimental, and is not deployed by the Helm chart. Its
+Deployment, Service, ValidatingWebhookConfiguration and serving certificate
+must be deployed separately. It must be given the same --driver-name,
+--default-attributes-file, --use-token-request, --continue-on-not-ready and
+--allowed-volume-readiness-gate-types as the driver.`
+
+	// webhookValidatePath is the path the validating webhook is served on.
+	webhookValidatePath = "/validate-pod"
+)
+
+// newWebhookCommand returns the webhook subcommand, which serves a validating
+// admission webhook for Pod CREATE requests. Configure a
+// ValidatingWebhookConfiguration for pods to call the webhookValidatePath on
+// this server.
+func newWebhookCommand(ctx context.Context) *cobra.Command {
+	opts := options.NewWebhook()
+
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: webhookHelpOutput,
+		Long:  webhookLongHelpOutput,
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Complete()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := opts.Logr.WithName("webhook")
+			ctrl.SetLogger(log)
+
+			if err := spec.ValidateTypes(opts.AllowedVolumeReadinessGateTypes); err != nil {
+				return fmt.Errorf("invalid --allowed-volume-readiness-gate-types: %w", err)
+			}
+			validationOpts := validation.Options{
+				SecretReferencesAllowed:         opts.UseTokenRequest,
+				PodAttributesAllowed:            opts.ContinueOnNotReady,
+				AllowedVolumeReadinessGateTypes: opts.AllowedVolumeReadinessGateTypes,
+			}
+			clusterDefaults, err := loadDefaultAttributesFile(opts.DefaultAttributesFile, validationOpts)
+			if err != nil {
+				return err
+			}
+
+			log.Info("Starting webhook", "version", version.VersionInfo(), "driver-name", opts.DriverName)
+
+			server := ctrlwebhook.NewServer(ctrlwebhook.Options{
+				Port:     opts.Port,
+				CertDir:  opts.CertDir,
+				CertName: opts.CertName,
+				KeyName:  opts.KeyName,
+			})
+			server.Register(webhookValidatePath, &ctrlwebhook.Admission{
+				Handler: webhook.NewValidator(opts.DriverName, clusterDefaults, validationOpts),
+			})
+			server.Register("/healthz", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			return server.Start(ctx)
+		},
+	}
+
+	opts.Prepare(cmd)
+
+	return cmd
+}
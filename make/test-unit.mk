@@ -25,3 +25,10 @@ test-unit: | $(NEEDS_GO) $(NEEDS_GOTESTSUM) $(ARTIFACTS)
 		-ldflags $(go_manager_ldflags)
 	
 	$(GO) tool cover -html=$(ARTIFACTS)/filtered.cov -o=$(ARTIFACTS)/filtered.html
+
+.PHONY: test-softhsm
+## PKCS#11 key backend tests against SoftHSM v2. Requires cgo, SoftHSM v2 and
+## softhsm2-util to be installed.
+## @category Testing
+test-softhsm: | $(NEEDS_GO)
+	CGO_ENABLED=1 SOFTHSM2_REQUIRED=true $(GO) test ./pkg/hsm/... -run SoftHSM -v
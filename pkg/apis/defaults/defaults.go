@@ -27,11 +27,16 @@ import (
 )
 
 // SetDefaultAttributes will set default values on the given attribute map.
+// The cluster-wide defaults configured by the operator, if any, are layered
+// under the attributes before the built-in defaults, and should have been
+// validated with validation.ValidateDefaultAttributes.
 // It will not modify the attributes in-place, and instead will return a copy.
-func SetDefaultAttributes(attrOriginal map[string]string) (map[string]string, error) {
+func SetDefaultAttributes(attrOriginal, clusterDefaults map[string]string) (map[string]string, error) {
 	attr := make(map[string]string)
 	maps.Copy(attr, attrOriginal)
 
+	setClusterDefaults(attr, clusterDefaults)
+
 	setDefaultIfEmpty(attr, csiapi.IssuerKindKey, cmapi.IssuerKind)
 	setDefaultIfEmpty(attr, csiapi.IssuerGroupKey, certmanager.GroupName)
 
@@ -42,6 +47,18 @@ func SetDefaultAttributes(attrOriginal map[string]string) (map[string]string, er
 	setDefaultIfEmpty(attr, csiapi.CertFileKey, "tls.crt")
 	setDefaultIfEmpty(attr, csiapi.KeyFileKey, "tls.key")
 
+	if _, ok := attr[csiapi.KeyPasswordSecretNameKey]; ok {
+		setDefaultIfEmpty(attr, csiapi.KeyPasswordSecretKeyKey, "password")
+	}
+
+	if attr[csiapi.KeyBackendKey] == csiapi.KeyBackendPKCS11 {
+		setDefaultIfEmpty(attr, csiapi.KeyURIFileKey, "tls.key.uri")
+	}
+
+	if len(attr[csiapi.CombinedPEMFileKey]) > 0 {
+		setDefaultIfEmpty(attr, csiapi.CombinedPEMOrderKey, csiapi.CombinedPEMOrderKeyFirst)
+	}
+
 	if alg := attr[csiapi.KeyAlgorithmKey]; alg != "" {
 		// If an algorithm was supplied by the user, normalize it's casing to
 		// match the constants, no matter what form it was in.
@@ -55,6 +72,8 @@ func SetDefaultAttributes(attrOriginal map[string]string) (map[string]string, er
 			attr[csiapi.KeyAlgorithmKey] = string(cmapi.ECDSAKeyAlgorithm)
 		case strings.EqualFold(alg, "ed25519"):
 			attr[csiapi.KeyAlgorithmKey] = string(cmapi.Ed25519KeyAlgorithm)
+		case strings.EqualFold(alg, "mldsa"):
+			attr[csiapi.KeyAlgorithmKey] = csiapi.MLDSAKeyAlgorithm
 		}
 	} else {
 		// Default the algorithm since it is unset.
@@ -86,15 +105,44 @@ func SetDefaultAttributes(attrOriginal map[string]string) (map[string]string, er
 	case string(cmapi.Ed25519KeyAlgorithm):
 		setDefaultIfEmpty(attr, csiapi.KeyEncodingKey, "PKCS8")
 		// No size is needed for Ed25519
+	case csiapi.MLDSAKeyAlgorithm:
+		setDefaultIfEmpty(attr, csiapi.KeyEncodingKey, "PKCS8")
+		setDefaultIfEmpty(attr, csiapi.KeySizeKey, "65")
 	}
 
 	setDefaultIfEmpty(attr, csiapi.KeyUsagesKey, strings.Join([]string{string(cmapi.UsageDigitalSignature), string(cmapi.UsageKeyEncipherment)}, ","))
 
 	setDefaultKeyStorePKCS12(attr)
+	setDefaultKeyStoreJKS(attr)
 
 	return attr, nil
 }
 
+// setClusterDefaults sets the given cluster-wide defaults on the attributes,
+// where they have not been set by the volume. Defaults which only make sense
+// alongside another default are skipped when the volume overrides it: the
+// issuer kind and group are only used when the issuer name is defaulted too,
+// and the key size and encoding only when the volume doesn't request a
+// different key algorithm.
+func setClusterDefaults(attr, defaults map[string]string) {
+	issuerName := attr[csiapi.IssuerNameKey]
+	keyAlgorithm := attr[csiapi.KeyAlgorithmKey]
+
+	for k, v := range defaults {
+		switch k {
+		case csiapi.IssuerKindKey, csiapi.IssuerGroupKey:
+			if len(issuerName) > 0 {
+				continue
+			}
+		case csiapi.KeySizeKey, csiapi.KeyEncodingKey:
+			if len(keyAlgorithm) > 0 && !strings.EqualFold(keyAlgorithm, defaults[csiapi.KeyAlgorithmKey]) {
+				continue
+			}
+		}
+		setDefaultIfEmpty(attr, k, v)
+	}
+}
+
 func setDefaultIfEmpty(attr map[string]string, k, v string) {
 	if len(attr[k]) == 0 {
 		attr[k] = v
@@ -105,9 +153,29 @@ func setDefaultIfEmpty(attr map[string]string, k, v string) {
 // attributes. If the csiapi.KeyStorePKCS12EnableKey key is not defined, omit
 // setting defaults on the other PKCS12 keys, since they should not be present
 // in the attributes at all. If the other attributes are present, then a
-// validation error will be picked up by validation.
+// validation error will be picked up by validation. The same applies to the
+// PKCS12 truststore and csiapi.TrustStorePKCS12EnableKey.
 func setDefaultKeyStorePKCS12(attr map[string]string) {
 	if _, ok := attr[csiapi.KeyStorePKCS12EnableKey]; ok {
 		setDefaultIfEmpty(attr, csiapi.KeyStorePKCS12FileKey, "keystore.p12")
+		if _, ok := attr[csiapi.KeyStorePKCS12PasswordSecretNameKey]; ok {
+			setDefaultIfEmpty(attr, csiapi.KeyStorePKCS12PasswordSecretKeyKey, "password")
+		}
+	}
+	if _, ok := attr[csiapi.TrustStorePKCS12EnableKey]; ok {
+		setDefaultIfEmpty(attr, csiapi.TrustStorePKCS12FileKey, "truststore.p12")
+	}
+}
+
+// setDefaultKeyStoreJKS sets the default values for the JKS relevant
+// attributes, in the same way as setDefaultKeyStorePKCS12. The default alias
+// matches cert-manager's Certificate keystores.
+func setDefaultKeyStoreJKS(attr map[string]string) {
+	if _, ok := attr[csiapi.KeyStoreJKSEnableKey]; ok {
+		setDefaultIfEmpty(attr, csiapi.KeyStoreJKSFileKey, "keystore.jks")
+		setDefaultIfEmpty(attr, csiapi.KeyStoreJKSAliasKey, "certificate")
+	}
+	if _, ok := attr[csiapi.TrustStoreJKSEnableKey]; ok {
+		setDefaultIfEmpty(attr, csiapi.TrustStoreJKSFileKey, "truststore.jks")
 	}
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package defaults
 
 import (
+	"maps"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,6 +41,15 @@ func Test_pkcs12Values(t *testing.T) {
 				"csi.cert-manager.io/pkcs12-filename": "keystore.p12",
 			},
 		},
+		"if PKCS12 truststore enable attribute present, expect PKCS12 truststore attributes present": {
+			input: map[string]string{
+				"csi.cert-manager.io/pkcs12-truststore-enable": "true",
+			},
+			expOutput: map[string]string{
+				"csi.cert-manager.io/pkcs12-truststore-enable":   "true",
+				"csi.cert-manager.io/pkcs12-truststore-filename": "truststore.p12",
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -50,3 +60,133 @@ func Test_pkcs12Values(t *testing.T) {
 		})
 	}
 }
+
+func Test_jksValues(t *testing.T) {
+	tests := map[string]struct {
+		input     map[string]string
+		expOutput map[string]string
+	}{
+		"if attributes are empty, expect no JKS attributes": {
+			input:     map[string]string{},
+			expOutput: map[string]string{},
+		},
+		"if JKS enable attribute present, expect JKS attributes present": {
+			input: map[string]string{
+				"csi.cert-manager.io/jks-enable": "true",
+			},
+			expOutput: map[string]string{
+				"csi.cert-manager.io/jks-enable":   "true",
+				"csi.cert-manager.io/jks-filename": "keystore.jks",
+				"csi.cert-manager.io/jks-alias":    "certificate",
+			},
+		},
+		"if JKS truststore enable attribute present, expect JKS truststore attributes present": {
+			input: map[string]string{
+				"csi.cert-manager.io/jks-truststore-enable": "true",
+			},
+			expOutput: map[string]string{
+				"csi.cert-manager.io/jks-truststore-enable":   "true",
+				"csi.cert-manager.io/jks-truststore-filename": "truststore.jks",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := test.input
+			setDefaultKeyStoreJKS(out)
+			assert.Equal(t, test.expOutput, out)
+		})
+	}
+}
+
+func Test_setClusterDefaults(t *testing.T) {
+	clusterDefaults := map[string]string{
+		"csi.cert-manager.io/issuer-name":   "internal-ca",
+		"csi.cert-manager.io/issuer-kind":   "ClusterIssuer",
+		"csi.cert-manager.io/key-algorithm": "ECDSA",
+		"csi.cert-manager.io/key-size":      "384",
+		"csi.cert-manager.io/key-encoding":  "PKCS8",
+	}
+
+	tests := map[string]struct {
+		input     map[string]string
+		expOutput map[string]string
+	}{
+		"if attributes are empty, expect all cluster defaults": {
+			input:     map[string]string{},
+			expOutput: clusterDefaults,
+		},
+		"if the volume sets an attribute, expect it to take precedence": {
+			input: map[string]string{
+				"csi.cert-manager.io/key-size": "256",
+			},
+			expOutput: map[string]string{
+				"csi.cert-manager.io/issuer-name":   "internal-ca",
+				"csi.cert-manager.io/issuer-kind":   "ClusterIssuer",
+				"csi.cert-manager.io/key-algorithm": "ECDSA",
+				"csi.cert-manager.io/key-size":      "256",
+				"csi.cert-manager.io/key-encoding":  "PKCS8",
+			},
+		},
+		"if the volume sets an issuer name, expect the issuer kind to not be defaulted": {
+			input: map[string]string{
+				"csi.cert-manager.io/issuer-name": "my-issuer",
+			},
+			expOutput: map[string]string{
+				"csi.cert-manager.io/issuer-name":   "my-issuer",
+				"csi.cert-manager.io/key-algorithm": "ECDSA",
+				"csi.cert-manager.io/key-size":      "384",
+				"csi.cert-manager.io/key-encoding":  "PKCS8",
+			},
+		},
+		"if the volume sets a different key algorithm, expect key size and encoding to not be defaulted": {
+			input: map[string]string{
+				"csi.cert-manager.io/key-algorithm": "RSA",
+			},
+			expOutput: map[string]string{
+				"csi.cert-manager.io/issuer-name":   "internal-ca",
+				"csi.cert-manager.io/issuer-kind":   "ClusterIssuer",
+				"csi.cert-manager.io/key-algorithm": "RSA",
+			},
+		},
+		"if the volume sets the same key algorithm in a different case, expect key size and encoding to be defaulted": {
+			input: map[string]string{
+				"csi.cert-manager.io/key-algorithm": "ecdsa",
+			},
+			expOutput: map[string]string{
+				"csi.cert-manager.io/issuer-name":   "internal-ca",
+				"csi.cert-manager.io/issuer-kind":   "ClusterIssuer",
+				"csi.cert-manager.io/key-algorithm": "ecdsa",
+				"csi.cert-manager.io/key-size":      "384",
+				"csi.cert-manager.io/key-encoding":  "PKCS8",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := maps.Clone(test.input)
+			setClusterDefaults(out, clusterDefaults)
+			assert.Equal(t, test.expOutput, out)
+		})
+	}
+}
+
+func Test_SetDefaultAttributesWithClusterDefaults(t *testing.T) {
+	out, err := SetDefaultAttributes(map[string]string{
+		"csi.cert-manager.io/issuer-name": "my-issuer",
+	}, map[string]string{
+		"csi.cert-manager.io/key-algorithm": "ECDSA",
+		"csi.cert-manager.io/duration":      "24h",
+	})
+	assert.NoError(t, err)
+
+	// Cluster defaults are applied, and the built-in defaults are derived
+	// from them.
+	assert.Equal(t, "ECDSA", out["csi.cert-manager.io/key-algorithm"])
+	assert.Equal(t, "256", out["csi.cert-manager.io/key-size"])
+	assert.Equal(t, "PKCS8", out["csi.cert-manager.io/key-encoding"])
+	assert.Equal(t, "24h", out["csi.cert-manager.io/duration"])
+	assert.Equal(t, "Issuer", out["csi.cert-manager.io/issuer-kind"])
+}
@@ -42,17 +42,104 @@ const (
 	KeyEncodingKey  = "csi.cert-manager.io/key-encoding"
 	KeySizeKey      = "csi.cert-manager.io/key-size"
 
+	// The private key password, given directly or read from a Secret in the
+	// pod's namespace.
+	KeyPasswordKey           = "csi.cert-manager.io/privatekey-password" // #nosec G101: False positive, gosec thinks this is a credential.
+	KeyPasswordSecretNameKey = "csi.cert-manager.io/privatekey-password-secret-name"
+	KeyPasswordSecretKeyKey  = "csi.cert-manager.io/privatekey-password-secret-key"
+
+	// IPSANsFromPodKey lists the sources of IP SANs read from the pod:
+	// "pod-ips" or "network:<name>".
+	IPSANsFromPodKey = "csi.cert-manager.io/ip-sans-from-pod"
+
+	// ReadinessGatesKey is a semicolon separated list of readiness gates
+	// which must pass before a request is created for the volume.
+	ReadinessGatesKey = "csi.cert-manager.io/readiness-gates"
+
 	CAFileKey   = "csi.cert-manager.io/ca-file"
 	CertFileKey = "csi.cert-manager.io/certificate-file"
 	KeyFileKey  = "csi.cert-manager.io/privatekey-file"
 	FSGroupKey  = "csi.cert-manager.io/fs-group"
 
+	// The octal modes of the files containing the private key and of every
+	// other file, and the UID owning every file.
+	KeyFileModeKey  = "csi.cert-manager.io/privatekey-file-mode"
+	CertFileModeKey = "csi.cert-manager.io/certificate-file-mode"
+	FileOwnerUIDKey = "csi.cert-manager.io/file-owner-uid"
+
+	// DER encoded leaf certificate, CA certificate and PKCS#8 private key
+	// files.
+	CADERFileKey   = "csi.cert-manager.io/ca-der-file"
+	CertDERFileKey = "csi.cert-manager.io/certificate-der-file"
+	KeyDERFileKey  = "csi.cert-manager.io/privatekey-der-file"
+
+	// Leaf certificate, intermediate certificates and full chain files.
+	LeafCertFileKey      = "csi.cert-manager.io/leaf-certificate-file"
+	IntermediatesFileKey = "csi.cert-manager.io/intermediates-file"
+	FullChainFileKey     = "csi.cert-manager.io/fullchain-file"
+
+	// A single PEM file containing the private key and certificate chain, in
+	// the given order.
+	CombinedPEMFileKey  = "csi.cert-manager.io/combined-pem-file"
+	CombinedPEMOrderKey = "csi.cert-manager.io/combined-pem-order"
+
+	// KeyBackendKey is where the private key is held: "software" or
+	// "pkcs11", in which case only its PKCS#11 URI is written.
+	KeyBackendKey = "csi.cert-manager.io/key-backend"
+	KeyURIFileKey = "csi.cert-manager.io/privatekey-uri-file"
+
 	RenewBeforeKey  = "csi.cert-manager.io/renew-before"
 	ReusePrivateKey = "csi.cert-manager.io/reuse-private-key"
 
 	KeyStorePKCS12EnableKey   = "csi.cert-manager.io/pkcs12-enable"
 	KeyStorePKCS12FileKey     = "csi.cert-manager.io/pkcs12-filename"
 	KeyStorePKCS12PasswordKey = "csi.cert-manager.io/pkcs12-password" // #nosec G101: False positive, gosec thinks this is a credential.
+	KeyStorePKCS12ProfileKey  = "csi.cert-manager.io/pkcs12-profile"
+
+	// The PKCS12 keystore password, read from a Secret in the pod's
+	// namespace.
+	KeyStorePKCS12PasswordSecretNameKey = "csi.cert-manager.io/pkcs12-password-secret-name"
+	KeyStorePKCS12PasswordSecretKeyKey  = "csi.cert-manager.io/pkcs12-password-secret-key"
+
+	// PKCS12 truststore containing only the CA certificates.
+	TrustStorePKCS12EnableKey   = "csi.cert-manager.io/pkcs12-truststore-enable"
+	TrustStorePKCS12FileKey     = "csi.cert-manager.io/pkcs12-truststore-filename"
+	TrustStorePKCS12PasswordKey = "csi.cert-manager.io/pkcs12-truststore-password" // #nosec G101: False positive, gosec thinks this is a credential.
+
+	KeyStoreJKSEnableKey   = "csi.cert-manager.io/jks-enable"
+	KeyStoreJKSFileKey     = "csi.cert-manager.io/jks-filename"
+	KeyStoreJKSPasswordKey = "csi.cert-manager.io/jks-password" // #nosec G101: False positive, gosec thinks this is a credential.
+	KeyStoreJKSAliasKey    = "csi.cert-manager.io/jks-alias"
+
+	TrustStoreJKSEnableKey   = "csi.cert-manager.io/jks-truststore-enable"
+	TrustStoreJKSFileKey     = "csi.cert-manager.io/jks-truststore-filename"
+	TrustStoreJKSPasswordKey = "csi.cert-manager.io/jks-truststore-password" // #nosec G101: False positive, gosec thinks this is a credential.
+)
+
+const (
+	// MLDSAKeyAlgorithm is the csi.cert-manager.io/key-algorithm of ML-DSA
+	// keys, which requires the MLDSAKeys feature gate.
+	MLDSAKeyAlgorithm = "MLDSA"
+)
+
+const (
+	// KeyBackendSoftware generates private keys in the driver, and writes
+	// them to the volume.
+	KeyBackendSoftware = "software"
+
+	// KeyBackendPKCS11 generates private keys in the driver's PKCS#11 token,
+	// and writes only their PKCS#11 URI to the volume.
+	KeyBackendPKCS11 = "pkcs11"
+)
+
+const (
+	// CombinedPEMOrderKeyFirst writes the private key before the certificate
+	// chain in the combined PEM file.
+	CombinedPEMOrderKeyFirst = "key-first"
+
+	// CombinedPEMOrderCertFirst writes the certificate chain before the
+	// private key in the combined PEM file.
+	CombinedPEMOrderCertFirst = "cert-first"
 )
 
 const (
@@ -18,6 +18,7 @@ package validation
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -27,13 +28,48 @@ import (
 	cmapiutil "github.com/cert-manager/cert-manager/pkg/api/util"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
+	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/feature"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate/spec"
 )
 
-// ValidateAttributes validates that the attributes provided
-func ValidateAttributes(attr map[string]string) field.ErrorList {
+// attributePrefix is the prefix of all csi-driver volume attribute keys.
+const attributePrefix = "csi.cert-manager.io/"
+
+// Options holds the driver configuration which volume attributes are
+// validated against.
+type Options struct {
+	// SecretReferencesAllowed is whether volumes may reference Secrets with
+	// the password Secret attributes, e.g.
+	// csi.cert-manager.io/privatekey-password-secret-name. Secrets are read
+	// as the pod's service account, so may only be referenced when the
+	// driver uses token requests.
+	SecretReferencesAllowed bool
+
+	// PodAttributesAllowed is whether volumes may use attributes read from
+	// the pod owning them: csi.cert-manager.io/ip-sans-from-pod, and the
+	// POD_IP, POD_IPS, POD_LABEL_<key> and POD_ANNOTATION_<key> variables.
+	// Pods have no IPs until their volumes are mounted, so the driver only
+	// reads pods when it continues on not ready.
+	PodAttributesAllowed bool
+
+	// AllowedVolumeReadinessGateTypes are the readiness gate types which
+	// volumes may use in csi.cert-manager.io/readiness-gates. If empty,
+	// volumes may not use readiness gates.
+	AllowedVolumeReadinessGateTypes []string
+}
+
+// podAttributesForbiddenDetail is the reason attributes read from the pod may
+// not be used.
+const podAttributesForbiddenDetail = "attributes read from the pod may only be used when the driver is run with --continue-on-not-ready"
+
+// ValidateAttributes validates that the attributes provided are valid for a
+// driver with the given options.
+func ValidateAttributes(attr map[string]string, opts Options) field.ErrorList {
 	var el field.ErrorList
 
 	path := field.NewPath("volumeAttributes")
@@ -48,23 +84,56 @@ func ValidateAttributes(attr map[string]string) field.ErrorList {
 
 	el = append(el, keyUsages(path.Child(csiapi.KeyUsagesKey), attr[csiapi.KeyUsagesKey])...)
 
+	el = append(el, ipSANsFromPod(path.Child(csiapi.IPSANsFromPodKey), attr[csiapi.IPSANsFromPodKey], opts)...)
+	el = append(el, podVariables(path, attr, opts)...)
+	el = append(el, readinessGates(path.Child(csiapi.ReadinessGatesKey), attr[csiapi.ReadinessGatesKey], opts)...)
+
 	el = append(el, filename(path.Child(csiapi.CAFileKey), attr[csiapi.CAFileKey])...)
 	el = append(el, filename(path.Child(csiapi.CertFileKey), attr[csiapi.CertFileKey])...)
 	el = append(el, filename(path.Child(csiapi.KeyFileKey), attr[csiapi.KeyFileKey])...)
+	el = append(el, filename(path.Child(csiapi.CADERFileKey), attr[csiapi.CADERFileKey])...)
+	el = append(el, filename(path.Child(csiapi.CertDERFileKey), attr[csiapi.CertDERFileKey])...)
+	el = append(el, filename(path.Child(csiapi.KeyDERFileKey), attr[csiapi.KeyDERFileKey])...)
+	el = append(el, filename(path.Child(csiapi.LeafCertFileKey), attr[csiapi.LeafCertFileKey])...)
+	el = append(el, filename(path.Child(csiapi.IntermediatesFileKey), attr[csiapi.IntermediatesFileKey])...)
+	el = append(el, filename(path.Child(csiapi.FullChainFileKey), attr[csiapi.FullChainFileKey])...)
+	el = append(el, combinedPEMValues(path, attr)...)
 	el = append(el, filename(path.Child(csiapi.KeyStorePKCS12FileKey), attr[csiapi.KeyStorePKCS12FileKey])...)
+	el = append(el, filename(path.Child(csiapi.TrustStorePKCS12FileKey), attr[csiapi.TrustStorePKCS12FileKey])...)
+	el = append(el, filename(path.Child(csiapi.KeyStoreJKSFileKey), attr[csiapi.KeyStoreJKSFileKey])...)
+	el = append(el, filename(path.Child(csiapi.TrustStoreJKSFileKey), attr[csiapi.TrustStoreJKSFileKey])...)
+
+	el = append(el, fileMode(path.Child(csiapi.KeyFileModeKey), attr[csiapi.KeyFileModeKey])...)
+	el = append(el, fileMode(path.Child(csiapi.CertFileModeKey), attr[csiapi.CertFileModeKey])...)
+	el = append(el, uid(path.Child(csiapi.FileOwnerUIDKey), attr[csiapi.FileOwnerUIDKey])...)
 
 	el = append(el, durationParse(path.Child(csiapi.RenewBeforeKey), attr[csiapi.RenewBeforeKey])...)
 	el = append(el, boolValue(path.Child(csiapi.ReusePrivateKey), attr[csiapi.ReusePrivateKey])...)
 
 	el = append(el, keyValue(path, attr)...)
+	el = append(el, keyBackendValues(path, attr)...)
+	el = append(el, passwordValues(path, attr, opts, csiapi.KeyPasswordKey, csiapi.KeyPasswordSecretNameKey, csiapi.KeyPasswordSecretKeyKey)...)
+
+	el = append(el, pkcs12Values(path, attr, opts)...)
 
-	el = append(el, pkcs12Values(path, attr)...)
+	el = append(el, jksValues(path, attr)...)
 
 	el = append(el, uniqueFilePaths(path, map[string]string{
-		csiapi.CAFileKey:             attr[csiapi.CAFileKey],
-		csiapi.CertFileKey:           attr[csiapi.CertFileKey],
-		csiapi.KeyFileKey:            attr[csiapi.KeyFileKey],
-		csiapi.KeyStorePKCS12FileKey: attr[csiapi.KeyStorePKCS12FileKey],
+		csiapi.CAFileKey:               attr[csiapi.CAFileKey],
+		csiapi.CertFileKey:             attr[csiapi.CertFileKey],
+		csiapi.KeyFileKey:              attr[csiapi.KeyFileKey],
+		csiapi.CADERFileKey:            attr[csiapi.CADERFileKey],
+		csiapi.CertDERFileKey:          attr[csiapi.CertDERFileKey],
+		csiapi.KeyDERFileKey:           attr[csiapi.KeyDERFileKey],
+		csiapi.KeyURIFileKey:           attr[csiapi.KeyURIFileKey],
+		csiapi.LeafCertFileKey:         attr[csiapi.LeafCertFileKey],
+		csiapi.IntermediatesFileKey:    attr[csiapi.IntermediatesFileKey],
+		csiapi.FullChainFileKey:        attr[csiapi.FullChainFileKey],
+		csiapi.CombinedPEMFileKey:      attr[csiapi.CombinedPEMFileKey],
+		csiapi.KeyStorePKCS12FileKey:   attr[csiapi.KeyStorePKCS12FileKey],
+		csiapi.TrustStorePKCS12FileKey: attr[csiapi.TrustStorePKCS12FileKey],
+		csiapi.KeyStoreJKSFileKey:      attr[csiapi.KeyStoreJKSFileKey],
+		csiapi.TrustStoreJKSFileKey:    attr[csiapi.TrustStoreJKSFileKey],
 	})...)
 
 	// If there are errors, then return not approved and the aggregated errors.
@@ -75,6 +144,43 @@ func ValidateAttributes(attr map[string]string) field.ErrorList {
 	return nil
 }
 
+// ValidateDefaultAttributes validates the cluster-wide default attributes,
+// as given to defaults.SetDefaultAttributes. Only csi.cert-manager.io
+// attributes may be defaulted, and they must be valid once the built-in
+// defaults have been applied. The issuer name is not required, since it may
+// be set by each volume instead.
+func ValidateDefaultAttributes(attr map[string]string, opts Options) field.ErrorList {
+	var el field.ErrorList
+
+	path := field.NewPath("defaultAttributes")
+
+	keys := make([]string, 0, len(attr))
+	for k := range attr {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !strings.HasPrefix(k, attributePrefix) {
+			el = append(el, field.Invalid(path.Key(k), k, fmt.Sprintf("only %s attributes may be defaulted", attributePrefix)))
+		}
+	}
+
+	attr, err := defaults.SetDefaultAttributes(attr, nil)
+	if err != nil {
+		return append(el, field.InternalError(path, err))
+	}
+
+	issuerNamePath := field.NewPath("volumeAttributes").Child(csiapi.IssuerNameKey).String()
+	for _, err := range ValidateAttributes(attr, opts) {
+		if err.Type == field.ErrorTypeRequired && err.Field == issuerNamePath {
+			continue
+		}
+		el = append(el, err)
+	}
+
+	return el
+}
+
 func keyUsages(path *field.Path, ss string) field.ErrorList {
 	if len(ss) == 0 {
 		return nil
@@ -95,6 +201,205 @@ func keyUsages(path *field.Path, ss string) field.ErrorList {
 	return el
 }
 
+// ipSANsFromPod validates a csi.cert-manager.io/ip-sans-from-pod value, which
+// is a comma separated list of "pod-ips" or "network:<name>" sources.
+func ipSANsFromPod(path *field.Path, ss string, opts Options) field.ErrorList {
+	if len(ss) == 0 {
+		return nil
+	}
+
+	if !opts.PodAttributesAllowed {
+		return field.ErrorList{field.Forbidden(path, podAttributesForbiddenDetail)}
+	}
+
+	var el field.ErrorList
+	for source := range strings.SplitSeq(ss, ",") {
+		source = strings.TrimSpace(source)
+		if source == "pod-ips" {
+			continue
+		}
+		if network, ok := strings.CutPrefix(source, "network:"); ok && len(network) > 0 {
+			continue
+		}
+		el = append(el, field.Invalid(path, source, `must be "pod-ips" or "network:<name>"`))
+	}
+
+	return el
+}
+
+// expandedKeys are the attributes in which variables are expanded.
+var expandedKeys = []string{
+	csiapi.LiteralSubjectKey, csiapi.CommonNameKey,
+	csiapi.OrganizationsKey, csiapi.OrganizationalUnitsKey, csiapi.CountriesKey,
+	csiapi.ProvincesKey, csiapi.LocalitiesKey, csiapi.StreetAddressesKey, csiapi.PostalCodesKey,
+	csiapi.DNSNamesKey, csiapi.IPSANsKey, csiapi.URISANsKey,
+}
+
+// podVariables ensures that the variables read from the pod are only used when
+// attributes may be read from the pod.
+func podVariables(path *field.Path, attr map[string]string, opts Options) field.ErrorList {
+	if opts.PodAttributesAllowed {
+		return nil
+	}
+
+	var el field.ErrorList
+	for _, key := range expandedKeys {
+		var usesPod bool
+		os.Expand(attr[key], func(name string) string {
+			usesPod = usesPod || name == "POD_IP" || name == "POD_IPS" ||
+				strings.HasPrefix(name, "POD_LABEL_") || strings.HasPrefix(name, "POD_ANNOTATION_")
+			return ""
+		})
+		if usesPod {
+			el = append(el, field.Forbidden(path.Child(key), podAttributesForbiddenDetail))
+		}
+	}
+	return el
+}
+
+// readinessGates validates a csi.cert-manager.io/readiness-gates value, which
+// is a semicolon separated list of "<type>:<value>" readiness gates of the
+// allowed types.
+func readinessGates(path *field.Path, s string, opts Options) field.ErrorList {
+	if _, err := spec.ParseAttribute(s, sets.New(opts.AllowedVolumeReadinessGateTypes...)); err != nil {
+		return field.ErrorList{field.Invalid(path, s, err.Error())}
+	}
+	return nil
+}
+
+// combinedPEMValues validates the combined PEM file attributes. The order
+// may only be set alongside the file.
+func combinedPEMValues(path *field.Path, attr map[string]string) field.ErrorList {
+	file, ok := attr[csiapi.CombinedPEMFileKey]
+	if !ok {
+		if order, ok := attr[csiapi.CombinedPEMOrderKey]; ok {
+			return field.ErrorList{field.Invalid(path.Child(csiapi.CombinedPEMOrderKey), order,
+				fmt.Sprintf("cannot use attribute without %q", csiapi.CombinedPEMFileKey))}
+		}
+		return nil
+	}
+
+	el := filename(path.Child(csiapi.CombinedPEMFileKey), file)
+	if len(file) == 0 {
+		el = append(el, field.Required(path.Child(csiapi.CombinedPEMFileKey), "must not be empty if set"))
+	}
+
+	switch order := attr[csiapi.CombinedPEMOrderKey]; order {
+	case "", csiapi.CombinedPEMOrderKeyFirst, csiapi.CombinedPEMOrderCertFirst:
+	default:
+		el = append(el, field.NotSupported(path.Child(csiapi.CombinedPEMOrderKey), order,
+			[]string{csiapi.CombinedPEMOrderKeyFirst, csiapi.CombinedPEMOrderCertFirst}))
+	}
+
+	return el
+}
+
+// keyBackendValues validates the key backend attributes. Keys held by the
+// PKCS#11 backend never leave the token, so no file which would contain the
+// private key may be requested.
+func keyBackendValues(path *field.Path, attr map[string]string) field.ErrorList {
+	uriFilePath := path.Child(csiapi.KeyURIFileKey)
+
+	switch backend := attr[csiapi.KeyBackendKey]; backend {
+	case "", csiapi.KeyBackendSoftware:
+		if file, ok := attr[csiapi.KeyURIFileKey]; ok {
+			return field.ErrorList{field.Invalid(uriFilePath, file,
+				fmt.Sprintf("cannot use attribute unless %q is %q", csiapi.KeyBackendKey, csiapi.KeyBackendPKCS11))}
+		}
+		return nil
+	case csiapi.KeyBackendPKCS11:
+	default:
+		return field.ErrorList{field.NotSupported(path.Child(csiapi.KeyBackendKey), backend,
+			[]string{csiapi.KeyBackendSoftware, csiapi.KeyBackendPKCS11})}
+	}
+
+	file := attr[csiapi.KeyURIFileKey]
+	el := filename(uriFilePath, file)
+	if len(file) == 0 {
+		el = append(el, field.Required(uriFilePath, "must not be empty if set"))
+	}
+
+	switch alg := attr[csiapi.KeyAlgorithmKey]; alg {
+	case string(cmapi.RSAKeyAlgorithm), string(cmapi.ECDSAKeyAlgorithm):
+	default:
+		el = append(el, field.NotSupported(path.Child(csiapi.KeyAlgorithmKey), alg,
+			[]cmapi.PrivateKeyAlgorithm{cmapi.RSAKeyAlgorithm, cmapi.ECDSAKeyAlgorithm}))
+	}
+
+	detail := fmt.Sprintf("cannot be used when %q is %q", csiapi.KeyBackendKey, csiapi.KeyBackendPKCS11)
+	for _, key := range []string{csiapi.KeyPasswordKey, csiapi.KeyPasswordSecretNameKey, csiapi.KeyDERFileKey, csiapi.CombinedPEMFileKey} {
+		if _, ok := attr[key]; ok {
+			el = append(el, field.Forbidden(path.Child(key), detail))
+		}
+	}
+	for _, key := range []string{csiapi.KeyStorePKCS12EnableKey, csiapi.KeyStoreJKSEnableKey} {
+		if attr[key] == "true" {
+			el = append(el, field.Forbidden(path.Child(key), detail))
+		}
+	}
+
+	return el
+}
+
+// passwordValues validates a password which may be given either directly by
+// the passwordKey attribute, or by a reference to a Secret with the
+// secretNameKey and secretKeyKey attributes, but not both. Secrets may only be
+// referenced if allowed by the options.
+func passwordValues(path *field.Path, attr map[string]string, opts Options, passwordKey, secretNameKey, secretKeyKey string) field.ErrorList {
+	var el field.ErrorList
+
+	password, hasPassword := attr[passwordKey]
+	secretName, hasSecretName := attr[secretNameKey]
+
+	if hasPassword && len(password) == 0 {
+		el = append(el, field.Required(path.Child(passwordKey), "must not be empty if set"))
+	}
+	if hasSecretName && len(secretName) == 0 {
+		el = append(el, field.Required(path.Child(secretNameKey), "must not be empty if set"))
+	}
+	if hasPassword && hasSecretName {
+		el = append(el, field.Invalid(path.Child(secretNameKey), secretName,
+			fmt.Sprintf("cannot be used with %q", passwordKey)))
+	}
+	if hasSecretName && !opts.SecretReferencesAllowed {
+		el = append(el, field.Forbidden(path.Child(secretNameKey),
+			"Secrets may only be referenced when the driver uses token requests"))
+	}
+	if secretKey, ok := attr[secretKeyKey]; ok && !hasSecretName {
+		el = append(el, field.Invalid(path.Child(secretKeyKey), secretKey,
+			fmt.Sprintf("cannot use attribute without %q", secretNameKey)))
+	}
+
+	return el
+}
+
+// fileMode validates an octal file mode, e.g. "0400". Only permission bits
+// may be set.
+func fileMode(path *field.Path, s string) field.ErrorList {
+	if len(s) == 0 {
+		return nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, s, "must be an octal file mode, e.g. \"0400\"")}
+	}
+	if mode > 0o777 {
+		return field.ErrorList{field.Invalid(path, s, "must not be greater than \"0777\"")}
+	}
+	return nil
+}
+
+// uid validates a user ID, which must be a non-negative 32 bit integer.
+func uid(path *field.Path, s string) field.ErrorList {
+	if len(s) == 0 {
+		return nil
+	}
+	if _, err := strconv.ParseUint(s, 10, 31); err != nil {
+		return field.ErrorList{field.Invalid(path, s, "must be a non-negative integer less than 2147483648")}
+	}
+	return nil
+}
+
 func durationParse(path *field.Path, s string) field.ErrorList {
 	if len(s) == 0 {
 		return nil
@@ -158,14 +463,41 @@ func keyValue(path *field.Path, attr map[string]string) field.ErrorList {
 		if size != "" {
 			return field.ErrorList{field.Invalid(sizePath, size, "size must be empty when using Ed25519 as the key algorithm")}
 		}
+	case csiapi.MLDSAKeyAlgorithm:
+		if !feature.DefaultFeatureGate.Enabled(feature.MLDSAKeys) {
+			return field.ErrorList{field.Invalid(algPath, alg, fmt.Sprintf("the %s feature gate must be enabled to use ML-DSA keys", feature.MLDSAKeys))}
+		}
+		if encoding == string(cmapi.PKCS1) {
+			return field.ErrorList{field.Invalid(encodingPath, encoding, "pkcs1 only supports rsa keys. ML-DSA keys must use pkcs8")}
+		}
+		switch size {
+		case "44", "65", "87":
+		default:
+			return field.ErrorList{field.NotSupported(sizePath, size, []string{"44", "65", "87"})}
+		}
 	default:
-		return field.ErrorList{field.NotSupported(algPath, alg, []cmapi.PrivateKeyAlgorithm{cmapi.RSAKeyAlgorithm, cmapi.ECDSAKeyAlgorithm, cmapi.Ed25519KeyAlgorithm})}
+		supported := []cmapi.PrivateKeyAlgorithm{cmapi.RSAKeyAlgorithm, cmapi.ECDSAKeyAlgorithm, cmapi.Ed25519KeyAlgorithm}
+		if feature.DefaultFeatureGate.Enabled(feature.MLDSAKeys) {
+			supported = append(supported, csiapi.MLDSAKeyAlgorithm)
+		}
+		return field.ErrorList{field.NotSupported(algPath, alg, supported)}
 	}
 
 	if encoding != string(cmapi.PKCS1) && encoding != string(cmapi.PKCS8) {
 		return field.ErrorList{field.NotSupported(encodingPath, encoding, []string{string(cmapi.PKCS1), string(cmapi.PKCS8)})}
 	}
 
+	// The private key DER file is always PKCS#8 encoded, which must match the
+	// encoding of the PEM private key file. Only PKCS#8 keys can be
+	// encrypted.
+	if encoding != string(cmapi.PKCS8) {
+		for _, key := range []string{csiapi.KeyDERFileKey, csiapi.KeyPasswordKey, csiapi.KeyPasswordSecretNameKey} {
+			if len(attr[key]) > 0 {
+				return field.ErrorList{field.Invalid(encodingPath, encoding, fmt.Sprintf("must be %q when %q is set", cmapi.PKCS8, key))}
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -207,11 +539,15 @@ func filename(path *field.Path, filename string) field.ErrorList {
 }
 
 // uniqueFilePaths returns an error when the given attributes and corresponding
-// file path values have a duplicate file path value.
+// file path values have a duplicate file path value. Empty values are for
+// files which are not written, and are ignored.
 func uniqueFilePaths(path *field.Path, paths map[string]string) field.ErrorList {
 	var el field.ErrorList
 
 	for k, v := range paths {
+		if len(v) == 0 {
+			continue
+		}
 		unique := make(map[string]struct{})
 		unique[v] = struct{}{}
 		for k2, v2 := range paths {
@@ -232,15 +568,18 @@ func uniqueFilePaths(path *field.Path, paths map[string]string) field.ErrorList
 }
 
 // pkcs12Values validates the PKCS12 attributes are valid.
-func pkcs12Values(path *field.Path, attr map[string]string) field.ErrorList {
+func pkcs12Values(path *field.Path, attr map[string]string, opts Options) field.ErrorList {
 	var el field.ErrorList
 
 	if enable := attr[csiapi.KeyStorePKCS12EnableKey]; len(enable) > 0 {
 		if file := attr[csiapi.KeyStorePKCS12FileKey]; len(file) == 0 {
 			el = append(el, field.Required(path.Child(csiapi.KeyStorePKCS12FileKey), "required attribute when PKCS12 KeyStore is enabled"))
 		}
-		if password := attr[csiapi.KeyStorePKCS12PasswordKey]; len(password) == 0 {
+		// The password may instead be read from a Secret.
+		if _, ok := attr[csiapi.KeyStorePKCS12PasswordSecretNameKey]; !ok && len(attr[csiapi.KeyStorePKCS12PasswordKey]) == 0 {
 			el = append(el, field.Required(path.Child(csiapi.KeyStorePKCS12PasswordKey), "required attribute when PKCS12 KeyStore is enabled"))
+		} else {
+			el = append(el, passwordValues(path, attr, opts, csiapi.KeyStorePKCS12PasswordKey, csiapi.KeyStorePKCS12PasswordSecretNameKey, csiapi.KeyStorePKCS12PasswordSecretKeyKey)...)
 		}
 
 		switch enable {
@@ -257,9 +596,120 @@ func pkcs12Values(path *field.Path, attr map[string]string) field.ErrorList {
 				fmt.Sprintf("cannot use attribute without %q set to %q or %q", csiapi.KeyStorePKCS12EnableKey, "true", "false")))
 		}
 
-		if password, ok := attr[csiapi.KeyStorePKCS12PasswordKey]; ok {
-			el = append(el, field.Invalid(path.Child(csiapi.KeyStorePKCS12PasswordKey), password,
-				fmt.Sprintf("cannot use attribute without %q set to %q or %q", csiapi.KeyStorePKCS12EnableKey, "true", "false")))
+		for _, key := range []string{csiapi.KeyStorePKCS12PasswordKey, csiapi.KeyStorePKCS12PasswordSecretNameKey, csiapi.KeyStorePKCS12PasswordSecretKeyKey} {
+			if value, ok := attr[key]; ok {
+				el = append(el, field.Invalid(path.Child(key), value,
+					fmt.Sprintf("cannot use attribute without %q set to %q or %q", csiapi.KeyStorePKCS12EnableKey, "true", "false")))
+			}
+		}
+	}
+
+	if enable := attr[csiapi.TrustStorePKCS12EnableKey]; len(enable) > 0 {
+		if file := attr[csiapi.TrustStorePKCS12FileKey]; len(file) == 0 {
+			el = append(el, field.Required(path.Child(csiapi.TrustStorePKCS12FileKey), "required attribute when PKCS12 TrustStore is enabled"))
+		}
+		if password := attr[csiapi.TrustStorePKCS12PasswordKey]; len(password) == 0 {
+			el = append(el, field.Required(path.Child(csiapi.TrustStorePKCS12PasswordKey), "required attribute when PKCS12 TrustStore is enabled"))
+		}
+
+		switch enable {
+		case "false", "true":
+		default:
+			el = append(el, field.NotSupported(path.Child(csiapi.TrustStorePKCS12EnableKey), enable, []string{"true", "false"}))
+		}
+
+	} else {
+		// No PKCS12 TrustStore attributes should be defined when the PKCS12
+		// TrustStore is not defined.
+
+		if file, ok := attr[csiapi.TrustStorePKCS12FileKey]; ok {
+			el = append(el, field.Invalid(path.Child(csiapi.TrustStorePKCS12FileKey), file,
+				fmt.Sprintf("cannot use attribute without %q set to %q or %q", csiapi.TrustStorePKCS12EnableKey, "true", "false")))
+		}
+
+		if password, ok := attr[csiapi.TrustStorePKCS12PasswordKey]; ok {
+			el = append(el, field.Invalid(path.Child(csiapi.TrustStorePKCS12PasswordKey), password,
+				fmt.Sprintf("cannot use attribute without %q set to %q or %q", csiapi.TrustStorePKCS12EnableKey, "true", "false")))
+		}
+	}
+
+	// The profile is used for both the KeyStore and the TrustStore. An empty
+	// profile uses LegacyRC2, the same as cert-manager's Certificate
+	// keystores.
+	if profile, ok := attr[csiapi.KeyStorePKCS12ProfileKey]; ok {
+		if len(attr[csiapi.KeyStorePKCS12EnableKey]) == 0 && len(attr[csiapi.TrustStorePKCS12EnableKey]) == 0 {
+			el = append(el, field.Invalid(path.Child(csiapi.KeyStorePKCS12ProfileKey), profile,
+				fmt.Sprintf("cannot use attribute without %q or %q set to %q or %q", csiapi.KeyStorePKCS12EnableKey, csiapi.TrustStorePKCS12EnableKey, "true", "false")))
+		} else {
+			switch cmapi.PKCS12Profile(profile) {
+			case "", cmapi.LegacyRC2PKCS12Profile, cmapi.LegacyDESPKCS12Profile, cmapi.Modern2023PKCS12Profile:
+			default:
+				el = append(el, field.NotSupported(path.Child(csiapi.KeyStorePKCS12ProfileKey), profile,
+					[]cmapi.PKCS12Profile{cmapi.LegacyRC2PKCS12Profile, cmapi.LegacyDESPKCS12Profile, cmapi.Modern2023PKCS12Profile}))
+			}
+		}
+	}
+
+	if len(el) > 0 {
+		return el
+	}
+
+	return nil
+}
+
+// jksValues validates the JKS attributes are valid.
+func jksValues(path *field.Path, attr map[string]string) field.ErrorList {
+	var el field.ErrorList
+
+	if enable := attr[csiapi.KeyStoreJKSEnableKey]; len(enable) > 0 {
+		if file := attr[csiapi.KeyStoreJKSFileKey]; len(file) == 0 {
+			el = append(el, field.Required(path.Child(csiapi.KeyStoreJKSFileKey), "required attribute when JKS KeyStore is enabled"))
+		}
+		if password := attr[csiapi.KeyStoreJKSPasswordKey]; len(password) == 0 {
+			el = append(el, field.Required(path.Child(csiapi.KeyStoreJKSPasswordKey), "required attribute when JKS KeyStore is enabled"))
+		}
+		if alias := attr[csiapi.KeyStoreJKSAliasKey]; len(alias) == 0 {
+			el = append(el, field.Required(path.Child(csiapi.KeyStoreJKSAliasKey), "required attribute when JKS KeyStore is enabled"))
+		}
+
+		switch enable {
+		case "false", "true":
+		default:
+			el = append(el, field.NotSupported(path.Child(csiapi.KeyStoreJKSEnableKey), enable, []string{"true", "false"}))
+		}
+
+	} else {
+		// No JKS attributes should be defined when JKS is not defined.
+		for _, key := range []string{csiapi.KeyStoreJKSFileKey, csiapi.KeyStoreJKSPasswordKey, csiapi.KeyStoreJKSAliasKey} {
+			if value, ok := attr[key]; ok {
+				el = append(el, field.Invalid(path.Child(key), value,
+					fmt.Sprintf("cannot use attribute without %q set to %q or %q", csiapi.KeyStoreJKSEnableKey, "true", "false")))
+			}
+		}
+	}
+
+	if enable := attr[csiapi.TrustStoreJKSEnableKey]; len(enable) > 0 {
+		if file := attr[csiapi.TrustStoreJKSFileKey]; len(file) == 0 {
+			el = append(el, field.Required(path.Child(csiapi.TrustStoreJKSFileKey), "required attribute when JKS TrustStore is enabled"))
+		}
+		if password := attr[csiapi.TrustStoreJKSPasswordKey]; len(password) == 0 {
+			el = append(el, field.Required(path.Child(csiapi.TrustStoreJKSPasswordKey), "required attribute when JKS TrustStore is enabled"))
+		}
+
+		switch enable {
+		case "false", "true":
+		default:
+			el = append(el, field.NotSupported(path.Child(csiapi.TrustStoreJKSEnableKey), enable, []string{"true", "false"}))
+		}
+
+	} else {
+		// No JKS TrustStore attributes should be defined when the JKS
+		// TrustStore is not defined.
+		for _, key := range []string{csiapi.TrustStoreJKSFileKey, csiapi.TrustStoreJKSPasswordKey} {
+			if value, ok := attr[key]; ok {
+				el = append(el, field.Invalid(path.Child(key), value,
+					fmt.Sprintf("cannot use attribute without %q set to %q or %q", csiapi.TrustStoreJKSEnableKey, "true", "false")))
+			}
 		}
 	}
 
@@ -24,8 +24,10 @@ import (
 	"github.com/cert-manager/cert-manager/pkg/util/pki"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
 
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/feature"
 )
 
 func Test_ValidateAttributes(t *testing.T) {
@@ -195,6 +197,44 @@ func Test_ValidateAttributes(t *testing.T) {
 				field.Duplicate(field.NewPath("volumeAttributes", "csi.cert-manager.io/privatekey-file"), "ca.crt"),
 			},
 		},
+		"setting a PKCS12 truststore filename which is duplicated should error": {
+			attr: map[string]string{
+				csiapi.IssuerNameKey:               "test-issuer",
+				csiapi.KeyEncodingKey:              "PKCS1",
+				csiapi.CAFileKey:                   "ca.crt",
+				csiapi.CertFileKey:                 "crt.tls",
+				csiapi.KeyFileKey:                  "key.tls",
+				csiapi.TrustStorePKCS12FileKey:     "ca.crt",
+				csiapi.TrustStorePKCS12EnableKey:   "true",
+				csiapi.TrustStorePKCS12PasswordKey: "password",
+				csiapi.KeyAlgorithmKey:             "RSA",
+				csiapi.KeySizeKey:                  "2048",
+			},
+			expErr: field.ErrorList{
+				field.Duplicate(field.NewPath("volumeAttributes", "csi.cert-manager.io/ca-file"), "ca.crt"),
+				field.Duplicate(field.NewPath("volumeAttributes", "csi.cert-manager.io/pkcs12-truststore-filename"), "ca.crt"),
+			},
+		},
+		"setting DER filenames which are invalid or duplicated should error": {
+			attr: map[string]string{
+				csiapi.IssuerNameKey:   "test-issuer",
+				csiapi.KeyEncodingKey:  "PKCS8",
+				csiapi.CAFileKey:       "ca.crt",
+				csiapi.CertFileKey:     "crt.tls",
+				csiapi.KeyFileKey:      "key.tls",
+				csiapi.CADERFileKey:    "ca.crt",
+				csiapi.CertDERFileKey:  "/crt.der",
+				csiapi.KeyDERFileKey:   "key.der",
+				csiapi.KeyAlgorithmKey: "RSA",
+				csiapi.KeySizeKey:      "2048",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(field.NewPath("volumeAttributes", "csi.cert-manager.io/certificate-der-file"), "/crt.der", "filename must not be an absolute path"),
+				field.Invalid(field.NewPath("volumeAttributes", "csi.cert-manager.io/certificate-der-file"), "/crt.der", "filename must not include '/'"),
+				field.Duplicate(field.NewPath("volumeAttributes", "csi.cert-manager.io/ca-der-file"), "ca.crt"),
+				field.Duplicate(field.NewPath("volumeAttributes", "csi.cert-manager.io/ca-file"), "ca.crt"),
+			},
+		},
 		"correct PKCS12 options should not error": {
 			attr: map[string]string{
 				csiapi.IssuerNameKey:             "test-issuer",
@@ -247,7 +287,7 @@ func Test_ValidateAttributes(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			assert.EqualValues(t, test.expErr, ValidateAttributes(test.attr))
+			assert.EqualValues(t, test.expErr, ValidateAttributes(test.attr, Options{}))
 		})
 	}
 }
@@ -276,6 +316,145 @@ func Test_durationParse(t *testing.T) {
 	}
 }
 
+func Test_ipSANsFromPod(t *testing.T) {
+	for name, test := range map[string]struct {
+		s                     string
+		disallowPodAttributes bool
+		expErr                field.ErrorList
+	}{
+		"no sources should not error": {
+			s:      "",
+			expErr: nil,
+		},
+		"no sources should not error when pod attributes are not allowed": {
+			s:                     "",
+			disallowPodAttributes: true,
+			expErr:                nil,
+		},
+		"sources should error when pod attributes are not allowed": {
+			s:                     "pod-ips",
+			disallowPodAttributes: true,
+			expErr: field.ErrorList{
+				field.Forbidden(field.NewPath("my-sources"), "attributes read from the pod may only be used when the driver is run with --continue-on-not-ready"),
+			},
+		},
+		"pod-ips and networks should not error": {
+			s:      "pod-ips, network:macvlan,network:my-namespace/sriov",
+			expErr: nil,
+		},
+		"unknown sources and empty network names should error": {
+			s: "pod-ip,network:",
+			expErr: field.ErrorList{
+				field.Invalid(field.NewPath("my-sources"), "pod-ip", `must be "pod-ips" or "network:<name>"`),
+				field.Invalid(field.NewPath("my-sources"), "network:", `must be "pod-ips" or "network:<name>"`),
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{PodAttributesAllowed: !test.disallowPodAttributes}
+			assert.Equal(t, test.expErr, ipSANsFromPod(field.NewPath("my-sources"), test.s, opts))
+		})
+	}
+}
+
+func Test_podVariables(t *testing.T) {
+	forbidden := "attributes read from the pod may only be used when the driver is run with --continue-on-not-ready"
+
+	for name, test := range map[string]struct {
+		attr               map[string]string
+		allowPodAttributes bool
+		expErr             field.ErrorList
+	}{
+		"other variables should not error": {
+			attr: map[string]string{
+				csiapi.CommonNameKey: "${POD_NAME}.${POD_NAMESPACE}",
+				csiapi.DNSNamesKey:   "${NODE_NAME}",
+			},
+			expErr: nil,
+		},
+		"pod variables should error in every expanded attribute": {
+			attr: map[string]string{
+				csiapi.CommonNameKey:    "$POD_IP",
+				csiapi.IPSANsKey:        "${POD_IPS}",
+				csiapi.URISANsKey:       "spiffe://td/${POD_NAME}/${POD_LABEL_app}",
+				csiapi.OrganizationsKey: "${POD_ANNOTATION_example.com/org}",
+			},
+			expErr: field.ErrorList{
+				field.Forbidden(field.NewPath("my-attrs", csiapi.CommonNameKey), forbidden),
+				field.Forbidden(field.NewPath("my-attrs", csiapi.OrganizationsKey), forbidden),
+				field.Forbidden(field.NewPath("my-attrs", csiapi.IPSANsKey), forbidden),
+				field.Forbidden(field.NewPath("my-attrs", csiapi.URISANsKey), forbidden),
+			},
+		},
+		"pod variables in attributes which are not expanded should not error": {
+			attr: map[string]string{
+				csiapi.KeyPasswordKey: "${POD_IP}",
+			},
+			expErr: nil,
+		},
+		"pod variables should not error when pod attributes are allowed": {
+			attr: map[string]string{
+				csiapi.IPSANsKey: "${POD_IP}",
+			},
+			allowPodAttributes: true,
+			expErr:             nil,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{PodAttributesAllowed: test.allowPodAttributes}
+			assert.Equal(t, test.expErr, podVariables(field.NewPath("my-attrs"), test.attr, opts))
+		})
+	}
+}
+
+func Test_readinessGates(t *testing.T) {
+	allowedTypes := []string{"pod-ip", "pod-annotation"}
+
+	for name, test := range map[string]struct {
+		s            string
+		allowedTypes []string
+		expErr       field.ErrorList
+	}{
+		"no gates should not error": {
+			s:      "",
+			expErr: nil,
+		},
+		"valid gates should not error": {
+			s:            "pod-ip:ipv6; pod-annotation:k8s.v1.cni.cncf.io/network-status",
+			allowedTypes: allowedTypes,
+			expErr:       nil,
+		},
+		"invalid gates should error": {
+			s:            "pod-ip:ipv6;pod-ip:dual-stack",
+			allowedTypes: allowedTypes,
+			expErr: field.ErrorList{
+				field.Invalid(field.NewPath("my-gates"), "pod-ip:ipv6;pod-ip:dual-stack",
+					`invalid readiness gate "pod-ip:dual-stack": pod-ip: unsupported family "dual-stack"; use any, ipv4, or ipv6`),
+			},
+		},
+		"gates of types which are not allowed should error": {
+			s:            "pod-ip:ipv6;pod-condition:Ready",
+			allowedTypes: allowedTypes,
+			expErr: field.ErrorList{
+				field.Invalid(field.NewPath("my-gates"), "pod-ip:ipv6;pod-condition:Ready",
+					`readiness gate "pod-condition:Ready" is not allowed: volumes may only use the types [pod-annotation pod-ip]`),
+			},
+		},
+		"gates should error when no types are allowed": {
+			s: "pod-ip:ipv6",
+			expErr: field.ErrorList{
+				field.Invalid(field.NewPath("my-gates"), "pod-ip:ipv6",
+					`readiness gate "pod-ip:ipv6" is not allowed: volumes may only use the types []`),
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{AllowedVolumeReadinessGateTypes: test.allowedTypes}
+			assert.Equal(t, test.expErr, readinessGates(field.NewPath("my-gates"), test.s, opts))
+		})
+	}
+}
+
 func Test_boolValue(t *testing.T) {
 	for name, test := range map[string]struct {
 		s      string
@@ -438,6 +617,35 @@ func Test_keyValue(t *testing.T) {
 			expErr: field.ErrorList{field.Invalid(field.NewPath("my-pkcs.csi.cert-manager.io/key-size"), "1", "size must be empty when using Ed25519 as the key algorithm")},
 		},
 
+		"private key DER file with PKCS8 should not error": {
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: string(cmapi.RSAKeyAlgorithm),
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS8),
+				csiapi.KeySizeKey:      "2048",
+				csiapi.KeyDERFileKey:   "key.der",
+			},
+			expErr: nil,
+		},
+		"private key DER file with PKCS1 should error": {
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: string(cmapi.RSAKeyAlgorithm),
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS1),
+				csiapi.KeySizeKey:      "2048",
+				csiapi.KeyDERFileKey:   "key.der",
+			},
+			expErr: field.ErrorList{field.Invalid(field.NewPath("my-pkcs.csi.cert-manager.io/key-encoding"), "PKCS1", `must be "PKCS8" when "csi.cert-manager.io/privatekey-der-file" is set`)},
+		},
+
+		"private key password with PKCS1 should error": {
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey:          string(cmapi.RSAKeyAlgorithm),
+				csiapi.KeyEncodingKey:           string(cmapi.PKCS1),
+				csiapi.KeySizeKey:               "2048",
+				csiapi.KeyPasswordSecretNameKey: "key-password",
+			},
+			expErr: field.ErrorList{field.Invalid(field.NewPath("my-pkcs.csi.cert-manager.io/key-encoding"), "PKCS1", `must be "PKCS8" when "csi.cert-manager.io/privatekey-password-secret-name" is set`)},
+		},
+
 		"missing encoding should error": {
 			attrs: map[string]string{
 				csiapi.KeyAlgorithmKey: "RSA",
@@ -474,12 +682,79 @@ func Test_keyValue(t *testing.T) {
 	}
 }
 
+func Test_keyValueMLDSA(t *testing.T) {
+	for name, test := range map[string]struct {
+		enabled bool
+		attrs   map[string]string
+		expErr  field.ErrorList
+	}{
+		"MLDSA with the feature gate disabled should error": {
+			enabled: false,
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: csiapi.MLDSAKeyAlgorithm,
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS8),
+				csiapi.KeySizeKey:      "65",
+			},
+			expErr: field.ErrorList{field.Invalid(field.NewPath("my-pkcs.csi.cert-manager.io/key-algorithm"), "MLDSA", "the MLDSAKeys feature gate must be enabled to use ML-DSA keys")},
+		},
+		"MLDSA with PKCS8 should not error": {
+			enabled: true,
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: csiapi.MLDSAKeyAlgorithm,
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS8),
+				csiapi.KeySizeKey:      "44",
+			},
+			expErr: nil,
+		},
+		"MLDSA with PKCS1 should error": {
+			enabled: true,
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: csiapi.MLDSAKeyAlgorithm,
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS1),
+				csiapi.KeySizeKey:      "65",
+			},
+			expErr: field.ErrorList{field.Invalid(field.NewPath("my-pkcs.csi.cert-manager.io/key-encoding"), "PKCS1", "pkcs1 only supports rsa keys. ML-DSA keys must use pkcs8")},
+		},
+		"MLDSA with an unsupported parameter set should error": {
+			enabled: true,
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: csiapi.MLDSAKeyAlgorithm,
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS8),
+				csiapi.KeySizeKey:      "2048",
+			},
+			expErr: field.ErrorList{field.NotSupported(field.NewPath("my-pkcs.csi.cert-manager.io/key-size"), "2048", []string{"44", "65", "87"})},
+		},
+		"an unknown algorithm should list MLDSA as supported with the feature gate enabled": {
+			enabled: true,
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: "bar",
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS8),
+			},
+			expErr: field.ErrorList{field.NotSupported(field.NewPath("my-pkcs.csi.cert-manager.io/key-algorithm"), "bar", []cmapi.PrivateKeyAlgorithm{cmapi.RSAKeyAlgorithm, cmapi.ECDSAKeyAlgorithm, cmapi.Ed25519KeyAlgorithm, csiapi.MLDSAKeyAlgorithm})},
+		},
+		"an unknown algorithm should not list MLDSA as supported with the feature gate disabled": {
+			enabled: false,
+			attrs: map[string]string{
+				csiapi.KeyAlgorithmKey: "bar",
+				csiapi.KeyEncodingKey:  string(cmapi.PKCS8),
+			},
+			expErr: field.ErrorList{field.NotSupported(field.NewPath("my-pkcs.csi.cert-manager.io/key-algorithm"), "bar", []cmapi.PrivateKeyAlgorithm{cmapi.RSAKeyAlgorithm, cmapi.ECDSAKeyAlgorithm, cmapi.Ed25519KeyAlgorithm})},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			featuregatetesting.SetFeatureGateDuringTest(t, feature.DefaultFeatureGate, feature.MLDSAKeys, test.enabled)
+			assert.Equal(t, test.expErr, keyValue(field.NewPath("my-pkcs"), test.attrs))
+		})
+	}
+}
+
 func Test_PKCS12Values(t *testing.T) {
 	basePath := field.NewPath("root")
 
 	tests := map[string]struct {
-		attr   map[string]string
-		expErr field.ErrorList
+		attr                     map[string]string
+		disallowSecretReferences bool
+		expErr                   field.ErrorList
 	}{
 		"if no attributes, expect no error": {
 			attr:   map[string]string{},
@@ -498,6 +773,53 @@ func Test_PKCS12Values(t *testing.T) {
 			},
 		},
 
+		"if a password Secret is defined, and enabled is defined as true, expect no error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-enable":               "true",
+				"csi.cert-manager.io/pkcs12-filename":             "my-file",
+				"csi.cert-manager.io/pkcs12-password-secret-name": "pkcs12-password",
+				"csi.cert-manager.io/pkcs12-password-secret-key":  "password",
+			},
+			expErr: nil,
+		},
+		"if a password Secret is defined, but Secret references are not allowed, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-enable":               "true",
+				"csi.cert-manager.io/pkcs12-filename":             "my-file",
+				"csi.cert-manager.io/pkcs12-password-secret-name": "pkcs12-password",
+				"csi.cert-manager.io/pkcs12-password-secret-key":  "password",
+			},
+			disallowSecretReferences: true,
+			expErr: field.ErrorList{
+				field.Forbidden(basePath.Child("csi.cert-manager.io/pkcs12-password-secret-name"),
+					"Secrets may only be referenced when the driver uses token requests"),
+			},
+		},
+		"if both a password and password Secret are defined, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-enable":               "true",
+				"csi.cert-manager.io/pkcs12-filename":             "my-file",
+				"csi.cert-manager.io/pkcs12-password":             "password",
+				"csi.cert-manager.io/pkcs12-password-secret-name": "pkcs12-password",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/pkcs12-password-secret-name"), "pkcs12-password",
+					"cannot be used with \"csi.cert-manager.io/pkcs12-password\""),
+			},
+		},
+		"if a password Secret is defined, but enabled is not defined, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-password-secret-name": "pkcs12-password",
+				"csi.cert-manager.io/pkcs12-password-secret-key":  "password",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/pkcs12-password-secret-name"), "pkcs12-password",
+					"cannot use attribute without \"csi.cert-manager.io/pkcs12-enable\" set to \"true\" or \"false\""),
+				field.Invalid(basePath.Child("csi.cert-manager.io/pkcs12-password-secret-key"), "password",
+					"cannot use attribute without \"csi.cert-manager.io/pkcs12-enable\" set to \"true\" or \"false\""),
+			},
+		},
+
 		"if key and password is defined, and enabled is defined as false, expect no error": {
 			attr: map[string]string{
 				"csi.cert-manager.io/pkcs12-enable":   "false",
@@ -516,6 +838,65 @@ func Test_PKCS12Values(t *testing.T) {
 				field.NotSupported(basePath.Child("csi.cert-manager.io/pkcs12-enable"), "foo", []string{"true", "false"}),
 			},
 		},
+		"if a supported profile is defined, and enabled is defined as true, expect no error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-enable":   "true",
+				"csi.cert-manager.io/pkcs12-filename": "my-file",
+				"csi.cert-manager.io/pkcs12-password": "password",
+				"csi.cert-manager.io/pkcs12-profile":  "Modern2023",
+			},
+			expErr: nil,
+		},
+		"if an unsupported profile is defined, and enabled is defined as true, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-enable":   "true",
+				"csi.cert-manager.io/pkcs12-filename": "my-file",
+				"csi.cert-manager.io/pkcs12-password": "password",
+				"csi.cert-manager.io/pkcs12-profile":  "modern2023",
+			},
+			expErr: field.ErrorList{
+				field.NotSupported(basePath.Child("csi.cert-manager.io/pkcs12-profile"), "modern2023", []cmapi.PKCS12Profile{"LegacyRC2", "LegacyDES", "Modern2023"}),
+			},
+		},
+		"if a profile is defined, but enabled is not defined, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-profile": "Modern2023",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/pkcs12-profile"), "Modern2023",
+					"cannot use attribute without \"csi.cert-manager.io/pkcs12-enable\" or \"csi.cert-manager.io/pkcs12-truststore-enable\" set to \"true\" or \"false\""),
+			},
+		},
+		"if a profile is defined, and only the truststore is enabled, expect no error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-truststore-enable":   "true",
+				"csi.cert-manager.io/pkcs12-truststore-filename": "my-file",
+				"csi.cert-manager.io/pkcs12-truststore-password": "password",
+				"csi.cert-manager.io/pkcs12-profile":             "Modern2023",
+			},
+			expErr: nil,
+		},
+		"if truststore filename and password is defined, but truststore enabled is not defined, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-truststore-filename": "my-file",
+				"csi.cert-manager.io/pkcs12-truststore-password": "password",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/pkcs12-truststore-filename"), "my-file",
+					"cannot use attribute without \"csi.cert-manager.io/pkcs12-truststore-enable\" set to \"true\" or \"false\""),
+				field.Invalid(basePath.Child("csi.cert-manager.io/pkcs12-truststore-password"), "password",
+					"cannot use attribute without \"csi.cert-manager.io/pkcs12-truststore-enable\" set to \"true\" or \"false\""),
+			},
+		},
+		"if truststore filename and password is not defined, and truststore enabled is defined as true, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/pkcs12-truststore-enable": "true",
+			},
+			expErr: field.ErrorList{
+				field.Required(basePath.Child("csi.cert-manager.io/pkcs12-truststore-filename"), "required attribute when PKCS12 TrustStore is enabled"),
+				field.Required(basePath.Child("csi.cert-manager.io/pkcs12-truststore-password"), "required attribute when PKCS12 TrustStore is enabled"),
+			},
+		},
 		"if key and password is not defined, and enabled is defined as true, expect error": {
 			attr: map[string]string{
 				"csi.cert-manager.io/pkcs12-enable": "true",
@@ -556,7 +937,383 @@ func Test_PKCS12Values(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			assert.EqualValues(t, test.expErr, pkcs12Values(basePath, test.attr))
+			opts := Options{SecretReferencesAllowed: !test.disallowSecretReferences}
+			assert.EqualValues(t, test.expErr, pkcs12Values(basePath, test.attr, opts))
+		})
+	}
+}
+
+func Test_JKSValues(t *testing.T) {
+	basePath := field.NewPath("root")
+
+	tests := map[string]struct {
+		attr   map[string]string
+		expErr field.ErrorList
+	}{
+		"if no attributes, expect no error": {
+			attr:   map[string]string{},
+			expErr: nil,
+		},
+		"if file, password and alias is defined, but enabled is not defined, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/jks-filename": "my-file",
+				"csi.cert-manager.io/jks-password": "password",
+				"csi.cert-manager.io/jks-alias":    "my-alias",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/jks-filename"), "my-file",
+					"cannot use attribute without \"csi.cert-manager.io/jks-enable\" set to \"true\" or \"false\""),
+				field.Invalid(basePath.Child("csi.cert-manager.io/jks-password"), "password",
+					"cannot use attribute without \"csi.cert-manager.io/jks-enable\" set to \"true\" or \"false\""),
+				field.Invalid(basePath.Child("csi.cert-manager.io/jks-alias"), "my-alias",
+					"cannot use attribute without \"csi.cert-manager.io/jks-enable\" set to \"true\" or \"false\""),
+			},
+		},
+		"if file, password and alias is defined, and enabled is defined as true, expect no error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/jks-enable":   "true",
+				"csi.cert-manager.io/jks-filename": "my-file",
+				"csi.cert-manager.io/jks-password": "password",
+				"csi.cert-manager.io/jks-alias":    "my-alias",
+			},
+			expErr: nil,
+		},
+		"if file, password and alias is defined, but enabled is defined as foo, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/jks-enable":   "foo",
+				"csi.cert-manager.io/jks-filename": "my-file",
+				"csi.cert-manager.io/jks-password": "password",
+				"csi.cert-manager.io/jks-alias":    "my-alias",
+			},
+			expErr: field.ErrorList{
+				field.NotSupported(basePath.Child("csi.cert-manager.io/jks-enable"), "foo", []string{"true", "false"}),
+			},
+		},
+		"if file, password and alias is not defined, and enabled is defined as true, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/jks-enable": "true",
+			},
+			expErr: field.ErrorList{
+				field.Required(basePath.Child("csi.cert-manager.io/jks-filename"), "required attribute when JKS KeyStore is enabled"),
+				field.Required(basePath.Child("csi.cert-manager.io/jks-password"), "required attribute when JKS KeyStore is enabled"),
+				field.Required(basePath.Child("csi.cert-manager.io/jks-alias"), "required attribute when JKS KeyStore is enabled"),
+			},
+		},
+		"if truststore file and password is defined, but truststore enabled is not defined, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/jks-truststore-filename": "my-file",
+				"csi.cert-manager.io/jks-truststore-password": "password",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/jks-truststore-filename"), "my-file",
+					"cannot use attribute without \"csi.cert-manager.io/jks-truststore-enable\" set to \"true\" or \"false\""),
+				field.Invalid(basePath.Child("csi.cert-manager.io/jks-truststore-password"), "password",
+					"cannot use attribute without \"csi.cert-manager.io/jks-truststore-enable\" set to \"true\" or \"false\""),
+			},
+		},
+		"if truststore file and password is not defined, and truststore enabled is defined as true, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/jks-truststore-enable": "true",
+			},
+			expErr: field.ErrorList{
+				field.Required(basePath.Child("csi.cert-manager.io/jks-truststore-filename"), "required attribute when JKS TrustStore is enabled"),
+				field.Required(basePath.Child("csi.cert-manager.io/jks-truststore-password"), "required attribute when JKS TrustStore is enabled"),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualValues(t, test.expErr, jksValues(basePath, test.attr))
+		})
+	}
+}
+
+func Test_combinedPEMValues(t *testing.T) {
+	basePath := field.NewPath("root")
+
+	tests := map[string]struct {
+		attr   map[string]string
+		expErr field.ErrorList
+	}{
+		"if no attributes, expect no error": {
+			attr:   map[string]string{},
+			expErr: nil,
+		},
+		"if file and a supported order is defined, expect no error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/combined-pem-file":  "tls.pem",
+				"csi.cert-manager.io/combined-pem-order": "cert-first",
+			},
+			expErr: nil,
+		},
+		"if file is empty, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/combined-pem-file": "",
+			},
+			expErr: field.ErrorList{
+				field.Required(basePath.Child("csi.cert-manager.io/combined-pem-file"), "must not be empty if set"),
+			},
+		},
+		"if file is not a valid filename, and the order is not supported, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/combined-pem-file":  "foo/tls.pem",
+				"csi.cert-manager.io/combined-pem-order": "chain-first",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/combined-pem-file"), "foo/tls.pem", "filename must not include '/'"),
+				field.NotSupported(basePath.Child("csi.cert-manager.io/combined-pem-order"), "chain-first", []string{"key-first", "cert-first"}),
+			},
+		},
+		"if order is defined, but file is not defined, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/combined-pem-order": "key-first",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/combined-pem-order"), "key-first",
+					"cannot use attribute without \"csi.cert-manager.io/combined-pem-file\""),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualValues(t, test.expErr, combinedPEMValues(basePath, test.attr))
+		})
+	}
+}
+
+func Test_keyBackendValues(t *testing.T) {
+	basePath := field.NewPath("root")
+	pkcs11Detail := "cannot be used when \"csi.cert-manager.io/key-backend\" is \"pkcs11\""
+
+	tests := map[string]struct {
+		attr   map[string]string
+		expErr field.ErrorList
+	}{
+		"if no attributes, expect no error": {
+			attr:   map[string]string{},
+			expErr: nil,
+		},
+		"if software backend, expect no error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/key-backend": "software",
+			},
+			expErr: nil,
+		},
+		"if uri file is defined without the pkcs11 backend, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/privatekey-uri-file": "tls.key.uri",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child("csi.cert-manager.io/privatekey-uri-file"), "tls.key.uri",
+					"cannot use attribute unless \"csi.cert-manager.io/key-backend\" is \"pkcs11\""),
+			},
+		},
+		"if backend is not supported, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/key-backend": "tpm",
+			},
+			expErr: field.ErrorList{
+				field.NotSupported(basePath.Child("csi.cert-manager.io/key-backend"), "tpm", []string{"software", "pkcs11"}),
+			},
+		},
+		"if pkcs11 backend with an ECDSA key, expect no error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/key-backend":         "pkcs11",
+				"csi.cert-manager.io/privatekey-uri-file": "tls.key.uri",
+				"csi.cert-manager.io/key-algorithm":       "ECDSA",
+				"csi.cert-manager.io/pkcs12-enable":       "false",
+			},
+			expErr: nil,
+		},
+		"if pkcs11 backend with an empty uri file and an Ed25519 key, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/key-backend":         "pkcs11",
+				"csi.cert-manager.io/privatekey-uri-file": "",
+				"csi.cert-manager.io/key-algorithm":       "Ed25519",
+			},
+			expErr: field.ErrorList{
+				field.Required(basePath.Child("csi.cert-manager.io/privatekey-uri-file"), "must not be empty if set"),
+				field.NotSupported(basePath.Child("csi.cert-manager.io/key-algorithm"), "Ed25519",
+					[]cmapi.PrivateKeyAlgorithm{cmapi.RSAKeyAlgorithm, cmapi.ECDSAKeyAlgorithm}),
+			},
+		},
+		"if pkcs11 backend with attributes which write the private key, expect error": {
+			attr: map[string]string{
+				"csi.cert-manager.io/key-backend":         "pkcs11",
+				"csi.cert-manager.io/privatekey-uri-file": "tls.key.uri",
+				"csi.cert-manager.io/key-algorithm":       "RSA",
+				"csi.cert-manager.io/privatekey-password": "foo",
+				"csi.cert-manager.io/combined-pem-file":   "tls.pem",
+				"csi.cert-manager.io/pkcs12-enable":       "true",
+			},
+			expErr: field.ErrorList{
+				field.Forbidden(basePath.Child("csi.cert-manager.io/privatekey-password"), pkcs11Detail),
+				field.Forbidden(basePath.Child("csi.cert-manager.io/combined-pem-file"), pkcs11Detail),
+				field.Forbidden(basePath.Child("csi.cert-manager.io/pkcs12-enable"), pkcs11Detail),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualValues(t, test.expErr, keyBackendValues(basePath, test.attr))
+		})
+	}
+}
+
+func Test_passwordValues(t *testing.T) {
+	basePath := field.NewPath("root")
+
+	const (
+		passwordKey   = "csi.cert-manager.io/privatekey-password"
+		secretNameKey = "csi.cert-manager.io/privatekey-password-secret-name"
+		secretKeyKey  = "csi.cert-manager.io/privatekey-password-secret-key"
+	)
+
+	tests := map[string]struct {
+		attr                     map[string]string
+		disallowSecretReferences bool
+		expErr                   field.ErrorList
+	}{
+		"if no attributes, expect no error": {
+			attr:   map[string]string{},
+			expErr: nil,
+		},
+		"if only a password is defined, expect no error": {
+			attr: map[string]string{
+				passwordKey: "password",
+			},
+			expErr: nil,
+		},
+		"if a Secret name and key are defined, expect no error": {
+			attr: map[string]string{
+				secretNameKey: "key-password",
+				secretKeyKey:  "password",
+			},
+			expErr: nil,
+		},
+		"if the password and Secret name are empty, expect error": {
+			attr: map[string]string{
+				passwordKey:   "",
+				secretNameKey: "",
+			},
+			expErr: field.ErrorList{
+				field.Required(basePath.Child(passwordKey), "must not be empty if set"),
+				field.Required(basePath.Child(secretNameKey), "must not be empty if set"),
+				field.Invalid(basePath.Child(secretNameKey), "", "cannot be used with \"csi.cert-manager.io/privatekey-password\""),
+			},
+		},
+		"if both a password and Secret name are defined, expect error": {
+			attr: map[string]string{
+				passwordKey:   "password",
+				secretNameKey: "key-password",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child(secretNameKey), "key-password", "cannot be used with \"csi.cert-manager.io/privatekey-password\""),
+			},
+		},
+		"if a Secret name is defined but Secret references are not allowed, expect error": {
+			attr: map[string]string{
+				secretNameKey: "key-password",
+				secretKeyKey:  "password",
+			},
+			disallowSecretReferences: true,
+			expErr: field.ErrorList{
+				field.Forbidden(basePath.Child(secretNameKey), "Secrets may only be referenced when the driver uses token requests"),
+			},
+		},
+		"if a Secret key is defined without a Secret name, expect error": {
+			attr: map[string]string{
+				secretKeyKey: "password",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(basePath.Child(secretKeyKey), "password", "cannot use attribute without \"csi.cert-manager.io/privatekey-password-secret-name\""),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{SecretReferencesAllowed: !test.disallowSecretReferences}
+			assert.EqualValues(t, test.expErr, passwordValues(basePath, test.attr, opts, passwordKey, secretNameKey, secretKeyKey))
+		})
+	}
+}
+
+func Test_fileMode(t *testing.T) {
+	basePath := field.NewPath("root")
+
+	tests := map[string]struct {
+		mode   string
+		expErr field.ErrorList
+	}{
+		"an empty mode should not error": {
+			mode:   "",
+			expErr: nil,
+		},
+		"an octal mode should not error": {
+			mode:   "0400",
+			expErr: nil,
+		},
+		"an octal mode without a leading zero should not error": {
+			mode:   "444",
+			expErr: nil,
+		},
+		"a non-octal mode should error": {
+			mode:   "0800",
+			expErr: field.ErrorList{field.Invalid(basePath, "0800", `must be an octal file mode, e.g. "0400"`)},
+		},
+		"a mode with more than the permission bits should error": {
+			mode:   "04755",
+			expErr: field.ErrorList{field.Invalid(basePath, "04755", `must not be greater than "0777"`)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expErr, fileMode(basePath, test.mode))
+		})
+	}
+}
+
+func Test_uid(t *testing.T) {
+	basePath := field.NewPath("root")
+
+	tests := map[string]struct {
+		uid    string
+		expErr field.ErrorList
+	}{
+		"an empty UID should not error": {
+			uid:    "",
+			expErr: nil,
+		},
+		"a UID of 0 should not error": {
+			uid:    "0",
+			expErr: nil,
+		},
+		"a positive UID should not error": {
+			uid:    "1000",
+			expErr: nil,
+		},
+		"a negative UID should error": {
+			uid:    "-1",
+			expErr: field.ErrorList{field.Invalid(basePath, "-1", "must be a non-negative integer less than 2147483648")},
+		},
+		"a UID greater than 32 bits should error": {
+			uid:    "2147483648",
+			expErr: field.ErrorList{field.Invalid(basePath, "2147483648", "must be a non-negative integer less than 2147483648")},
+		},
+		"a non-integer UID should error": {
+			uid:    "root",
+			expErr: field.ErrorList{field.Invalid(basePath, "root", "must be a non-negative integer less than 2147483648")},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expErr, uid(basePath, test.uid))
 		})
 	}
 }
@@ -686,3 +1443,57 @@ func Test_uniqueFilePaths(t *testing.T) {
 		})
 	}
 }
+
+func Test_ValidateDefaultAttributes(t *testing.T) {
+	path := field.NewPath("volumeAttributes")
+
+	tests := map[string]struct {
+		attr   map[string]string
+		expErr field.ErrorList
+	}{
+		"no default attributes should not error": {
+			attr:   map[string]string{},
+			expErr: nil,
+		},
+		"default attributes without an issuer name should not error": {
+			attr: map[string]string{
+				csiapi.KeyAlgorithmKey: "ECDSA",
+				csiapi.KeyEncodingKey:  "PKCS8",
+				csiapi.IssuerKindKey:   "ClusterIssuer",
+			},
+			expErr: nil,
+		},
+		"attributes not owned by the driver should error": {
+			attr: map[string]string{
+				csiapi.IssuerNameKey:                         "internal-ca",
+				csiapi.K8sVolumeContextKeyPodName:            "my-pod",
+				"example.com/foo":                            "bar",
+				csiapi.K8sVolumeContextKeyPodNamespace:       "sandbox",
+				csiapi.K8sVolumeContextKeyServiceAccountName: "default",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(field.NewPath("defaultAttributes").Key(csiapi.K8sVolumeContextKeyPodName), csiapi.K8sVolumeContextKeyPodName, "only csi.cert-manager.io/ attributes may be defaulted"),
+				field.Invalid(field.NewPath("defaultAttributes").Key(csiapi.K8sVolumeContextKeyPodNamespace), csiapi.K8sVolumeContextKeyPodNamespace, "only csi.cert-manager.io/ attributes may be defaulted"),
+				field.Invalid(field.NewPath("defaultAttributes").Key(csiapi.K8sVolumeContextKeyServiceAccountName), csiapi.K8sVolumeContextKeyServiceAccountName, "only csi.cert-manager.io/ attributes may be defaulted"),
+				field.Invalid(field.NewPath("defaultAttributes").Key("example.com/foo"), "example.com/foo", "only csi.cert-manager.io/ attributes may be defaulted"),
+			},
+		},
+		"invalid default attributes should error": {
+			attr: map[string]string{
+				csiapi.KeyAlgorithmKey: "ECDSA",
+				csiapi.KeySizeKey:      "2048",
+				csiapi.DurationKey:     "forever",
+			},
+			expErr: field.ErrorList{
+				field.Invalid(path.Child(csiapi.DurationKey), "forever", `must be a valid duration string: time: invalid duration "forever"`),
+				field.NotSupported(path.Child(csiapi.KeySizeKey), "2048", []string{"256", "384", "521"}),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expErr, ValidateDefaultAttributes(test.attr, Options{}))
+		})
+	}
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events posts Kubernetes Events on the Pod owning a volume, so that
+// application teams can see why their certificate has not been issued using
+// `kubectl describe pod`, without access to the driver's logs.
+//
+// Events are recorded by wrapping the functions passed to csi-lib's
+// manager.Options (see Recorder.Instrument).
+package events
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"sync"
+	"time"
+
+	apiutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmclient "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
+	"github.com/cert-manager/csi-lib/manager"
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/cert-manager/csi-lib/storage"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/policy"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate"
+)
+
+// VolumeIDAnnotationKey is the annotation set on every CertificateRequest to
+// the ID of the volume it was created for, so that the request of a failed
+// attempt can be found. Volume attributes can't set annotations in the
+// csi.cert-manager.io group, so it can't be overridden by a volume.
+const VolumeIDAnnotationKey = "csi.cert-manager.io/volume-id"
+
+const (
+	// ReasonInvalidAttributes is used when the volume attributes fail
+	// validation, or a CertificateRequest cannot be built from them.
+	ReasonInvalidAttributes = "InvalidAttributes"
+
+	// ReasonPolicyDenied is used when the request for the volume violates
+	// the driver's issuance policy for the pod's namespace.
+	ReasonPolicyDenied = "PolicyDenied"
+
+	// ReasonIssuanceFailed is used when issuance fails for any reason other
+	// than invalid attributes, e.g. the private key cannot be generated or
+	// the signed certificate cannot be written.
+	ReasonIssuanceFailed = "IssuanceFailed"
+
+	// ReasonCertificateRequestDenied is used when the CertificateRequest for
+	// the volume was denied by an approver.
+	ReasonCertificateRequestDenied = "CertificateRequestDenied"
+
+	// ReasonCertificateRequestFailed is used when the CertificateRequest for
+	// the volume was marked as failed or invalid by the issuer.
+	ReasonCertificateRequestFailed = "CertificateRequestFailed"
+
+	// ReasonWaitingForReadinessGates is used when issuance is deferred by one
+	// or more readiness gates.
+	ReasonWaitingForReadinessGates = "WaitingForReadinessGates"
+
+	// ReasonReadinessGatesTimedOut is used when the volume was not ready
+	// within the readiness gate timeout.
+	ReasonReadinessGatesTimedOut = "ReadinessGatesTimedOut"
+
+	// ReasonIssued is used when a certificate is written to the volume for
+	// the first time.
+	ReasonIssued = "Issued"
+
+	// ReasonRenewed is used when a renewed certificate is written to the
+	// volume.
+	ReasonRenewed = "Renewed"
+)
+
+// CorrelatorOptions returns the options used by the event broadcaster to
+// aggregate and rate limit events. The token bucket is keyed by the source
+// and the involved Pod, so a renewal loop for a single volume can post at
+// most BurstSize events before being throttled to one every 5 minutes.
+func CorrelatorOptions() record.CorrelatorOptions {
+	return record.CorrelatorOptions{
+		BurstSize: 10,
+		QPS:       1. / 300.,
+	}
+}
+
+// lookupTimeout is the maximum time spent looking up the CertificateRequest
+// of a failed attempt.
+const lookupTimeout = 10 * time.Second
+
+// Recorder posts Events on the Pod owning a volume.
+type Recorder struct {
+	recorder record.EventRecorder
+	cmClient cmclient.Interface
+	volumes  storage.MetadataReader
+
+	lock sync.Mutex
+	// pending holds the volumes with an issuance attempt which has not yet
+	// written a keypair.
+	pending map[string]struct{}
+	// gateReasons holds the last reported readiness gate reason for each
+	// volume, so that an Event is only posted when the reason changes.
+	gateReasons map[string]string
+}
+
+// New constructs a new Recorder which posts Events using the given recorder.
+// The cert-manager client is used to look up the CertificateRequest of a
+// failed issuance attempt, to report whether it was denied or failed. The
+// volumes reader is used to forget state about volumes which have since been
+// removed.
+func New(recorder record.EventRecorder, cmClient cmclient.Interface, volumes storage.MetadataReader) *Recorder {
+	return &Recorder{
+		recorder:    recorder,
+		cmClient:    cmClient,
+		volumes:     volumes,
+		pending:     make(map[string]struct{}),
+		gateReasons: make(map[string]string),
+	}
+}
+
+// Instrument wraps the functions of the given manager options so that Events
+// are posted on the Pod owning each volume. It must be called after those
+// functions, including the optional ReadyToRequest, have been set.
+func (r *Recorder) Instrument(opts *manager.Options) {
+	generatePrivateKey := opts.GeneratePrivateKey
+	generateRequest := opts.GenerateRequest
+	signRequest := opts.SignRequest
+	writeKeypair := opts.WriteKeypair
+	readyToRequest := opts.ReadyToRequest
+
+	opts.GeneratePrivateKey = func(meta metadata.Metadata) (crypto.PrivateKey, error) {
+		r.startAttempt(meta)
+		key, err := generatePrivateKey(meta)
+		if err != nil {
+			r.warning(meta, reasonForError(err), "Failed to generate private key: %v", err)
+		}
+		return key, err
+	}
+
+	opts.GenerateRequest = func(meta metadata.Metadata) (*manager.CertificateRequestBundle, error) {
+		bundle, err := generateRequest(meta)
+		if bundle != nil {
+			if bundle.Annotations == nil {
+				bundle.Annotations = make(map[string]string)
+			}
+			bundle.Annotations[VolumeIDAnnotationKey] = meta.VolumeID
+		}
+		if err != nil {
+			reason := ReasonInvalidAttributes
+			var denied *policy.DeniedError
+			if errors.As(err, &denied) {
+				reason = ReasonPolicyDenied
+			}
+			r.warning(meta, reason, "Failed to generate certificate request: %v", err)
+		}
+		return bundle, err
+	}
+
+	opts.SignRequest = func(meta metadata.Metadata, key crypto.PrivateKey, request *x509.CertificateRequest) ([]byte, error) {
+		csr, err := signRequest(meta, key, request)
+		if err != nil {
+			r.warning(meta, ReasonIssuanceFailed, "Failed to sign certificate request: %v", err)
+		}
+		return csr, err
+	}
+
+	opts.WriteKeypair = func(meta metadata.Metadata, key crypto.PrivateKey, chain []byte, ca []byte) error {
+		if err := writeKeypair(meta, key, chain, ca); err != nil {
+			r.warning(meta, reasonForError(err), "Failed to write certificate: %v", err)
+			return err
+		}
+
+		r.lock.Lock()
+		delete(r.pending, meta.VolumeID)
+		r.lock.Unlock()
+
+		// The metadata passed to WriteKeypair is that of the volume before the
+		// write, so a NextIssuanceTime is only present if a certificate was
+		// previously written.
+		if meta.NextIssuanceTime != nil {
+			r.normal(meta, ReasonRenewed, "Renewed certificate for volume %s", meta.VolumeID)
+		} else {
+			r.normal(meta, ReasonIssued, "Issued certificate for volume %s", meta.VolumeID)
+		}
+		return nil
+	}
+
+	if readyToRequest != nil {
+		opts.ReadyToRequest = func(meta metadata.Metadata) (bool, string) {
+			ready, reason := readyToRequest(meta)
+
+			r.lock.Lock()
+			changed := r.gateReasons[meta.VolumeID] != reason
+			if ready {
+				delete(r.gateReasons, meta.VolumeID)
+			} else {
+				r.gateReasons[meta.VolumeID] = reason
+			}
+			r.lock.Unlock()
+
+			if !ready && changed {
+				r.normal(meta, ReasonWaitingForReadinessGates, "Waiting to request certificate: %s", reason)
+			}
+			return ready, reason
+		}
+	}
+}
+
+// ReadinessGatesTimedOut posts a Warning Event on the Pod owning the volume,
+// reporting that it was not ready within the given timeout, and whether its
+// certificate is being requested anyway or has been given up on.
+func (r *Recorder) ReadinessGatesTimedOut(meta metadata.Metadata, timeout time.Duration, timeoutPolicy readinessgate.TimeoutPolicy, reason string) {
+	if timeoutPolicy == readinessgate.TimeoutPolicyIssue {
+		r.warning(meta, ReasonReadinessGatesTimedOut, "Not ready to request certificate after %s, requesting it without the pod IPs not yet assigned: %s", timeout, reason)
+		return
+	}
+	r.warning(meta, ReasonReadinessGatesTimedOut, "Not ready to request certificate after %s, giving up: %s", timeout, reason)
+}
+
+// startAttempt marks the start of an issuance attempt for the volume. If the
+// previous attempt never wrote a keypair, the CertificateRequest it created
+// is inspected to report whether it was denied or failed.
+func (r *Recorder) startAttempt(meta metadata.Metadata) {
+	r.prune()
+
+	r.lock.Lock()
+	_, retry := r.pending[meta.VolumeID]
+	r.pending[meta.VolumeID] = struct{}{}
+	r.lock.Unlock()
+
+	if retry {
+		r.reportPreviousRequest(meta)
+	}
+}
+
+// prune forgets the state of volumes which are no longer present in the
+// store, so it doesn't grow unbounded over the lifetime of the driver.
+func (r *Recorder) prune() {
+	if r.volumes == nil {
+		return
+	}
+	volumeIDs, err := r.volumes.ListVolumes()
+	if err != nil {
+		return
+	}
+	present := make(map[string]struct{}, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		present[volumeID] = struct{}{}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for volumeID := range r.pending {
+		if _, ok := present[volumeID]; !ok {
+			delete(r.pending, volumeID)
+		}
+	}
+	for volumeID := range r.gateReasons {
+		if _, ok := present[volumeID]; !ok {
+			delete(r.gateReasons, volumeID)
+		}
+	}
+}
+
+// reportPreviousRequest posts a Warning Event if the most recent
+// CertificateRequest created for the volume was denied or failed. The
+// CertificateRequests owned by the Pod are listed, and matched to the volume
+// by its VolumeIDAnnotationKey annotation.
+func (r *Recorder) reportPreviousRequest(meta metadata.Metadata) {
+	namespace := meta.VolumeContext[csiapi.K8sVolumeContextKeyPodNamespace]
+	podUID := types.UID(meta.VolumeContext[csiapi.K8sVolumeContextKeyPodUID])
+	if r.cmClient == nil || namespace == "" || podUID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	crList, err := r.cmClient.CertmanagerV1().CertificateRequests(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return
+	}
+
+	var latest *cmapi.CertificateRequest
+	for i, cr := range crList.Items {
+		if !ownedBy(&cr, podUID) || cr.Annotations[VolumeIDAnnotationKey] != meta.VolumeID {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&cr.CreationTimestamp) {
+			latest = &crList.Items[i]
+		}
+	}
+	if latest == nil {
+		return
+	}
+
+	switch {
+	case apiutil.CertificateRequestIsDenied(latest):
+		cond := apiutil.GetCertificateRequestCondition(latest, cmapi.CertificateRequestConditionDenied)
+		r.warning(meta, ReasonCertificateRequestDenied, "CertificateRequest %s was denied: %s", latest.Name, cond.Message)
+	case apiutil.CertificateRequestHasInvalidRequest(latest):
+		r.warning(meta, ReasonCertificateRequestFailed, "CertificateRequest %s is invalid: %s", latest.Name, apiutil.CertificateRequestInvalidRequestMessage(latest))
+	case apiutil.CertificateRequestReadyReason(latest) == cmapi.CertificateRequestReasonFailed:
+		cond := apiutil.GetCertificateRequestCondition(latest, cmapi.CertificateRequestConditionReady)
+		r.warning(meta, ReasonCertificateRequestFailed, "CertificateRequest %s failed: %s", latest.Name, cond.Message)
+	}
+}
+
+func (r *Recorder) normal(meta metadata.Metadata, reason, messageFmt string, args ...any) {
+	r.event(meta, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+func (r *Recorder) warning(meta metadata.Metadata, reason, messageFmt string, args ...any) {
+	r.event(meta, corev1.EventTypeWarning, reason, messageFmt, args...)
+}
+
+// event posts an Event on the Pod named in the volume context. Events are
+// dropped if the volume context does not name a Pod.
+func (r *Recorder) event(meta metadata.Metadata, eventType, reason, messageFmt string, args ...any) {
+	ref := podReference(meta)
+	if ref == nil {
+		return
+	}
+	r.recorder.Eventf(ref, eventType, reason, messageFmt, args...)
+}
+
+// podReference returns a reference to the Pod named in the volume context, or
+// nil if it is not present.
+func podReference(meta metadata.Metadata) *corev1.ObjectReference {
+	name := meta.VolumeContext[csiapi.K8sVolumeContextKeyPodName]
+	namespace := meta.VolumeContext[csiapi.K8sVolumeContextKeyPodNamespace]
+	if name == "" || namespace == "" {
+		return nil
+	}
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       name,
+		Namespace:  namespace,
+		UID:        types.UID(meta.VolumeContext[csiapi.K8sVolumeContextKeyPodUID]),
+	}
+}
+
+// reasonForError returns ReasonInvalidAttributes if the error is the result
+// of attribute validation, and ReasonIssuanceFailed otherwise.
+func reasonForError(err error) string {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		return ReasonInvalidAttributes
+	}
+	return ReasonIssuanceFailed
+}
+
+func ownedBy(cr *cmapi.CertificateRequest, uid types.UID) bool {
+	for _, ref := range cr.OwnerReferences {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmfake "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned/fake"
+	"github.com/cert-manager/csi-lib/manager"
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/cert-manager/csi-driver/pkg/policy"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate"
+)
+
+func testMetadata() metadata.Metadata {
+	return metadata.Metadata{
+		VolumeID: "vol-id",
+		VolumeContext: map[string]string{
+			"csi.cert-manager.io/issuer-name":  "my-issuer",
+			"csi.storage.k8s.io/pod.name":      "my-pod",
+			"csi.storage.k8s.io/pod.namespace": "my-namespace",
+			"csi.storage.k8s.io/pod.uid":       "my-pod-uid",
+		},
+	}
+}
+
+func Test_Instrument(t *testing.T) {
+	errInvalid := field.ErrorList{field.Required(field.NewPath("volumeAttributes"), "required")}.ToAggregate()
+
+	deniedRequest := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "my-request",
+			Namespace:       "my-namespace",
+			Annotations:     map[string]string{VolumeIDAnnotationKey: "vol-id"},
+			OwnerReferences: []metav1.OwnerReference{{UID: "my-pod-uid"}},
+		},
+		Status: cmapi.CertificateRequestStatus{
+			Conditions: []cmapi.CertificateRequestCondition{{
+				Type:    cmapi.CertificateRequestConditionDenied,
+				Status:  cmmeta.ConditionTrue,
+				Message: "not allowed",
+			}},
+		},
+	}
+
+	otherVolumeRequest := deniedRequest.DeepCopy()
+	otherVolumeRequest.Name = "other-request"
+	otherVolumeRequest.Annotations = map[string]string{VolumeIDAnnotationKey: "other-vol-id"}
+
+	tests := map[string]struct {
+		existing []*cmapi.CertificateRequest
+
+		generatePrivateKeyErr error
+		generateRequestErr    error
+		writeKeypairErr       error
+		gateReasons           []string
+
+		// run executes the wrapped manager functions.
+		run       func(opts manager.Options)
+		expEvents []string
+	}{
+		"invalid attributes during key generation should post a warning": {
+			generatePrivateKeyErr: errInvalid,
+			run: func(opts manager.Options) {
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+			},
+			expEvents: []string{
+				"Warning InvalidAttributes Failed to generate private key: volumeAttributes: Required value: required",
+			},
+		},
+		"other key generation errors should post an issuance failed warning": {
+			generatePrivateKeyErr: errors.New("disk full"),
+			run: func(opts manager.Options) {
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+			},
+			expEvents: []string{
+				"Warning IssuanceFailed Failed to generate private key: disk full",
+			},
+		},
+		"request generation errors should post an invalid attributes warning": {
+			generateRequestErr: errors.New(`undefined variable "Foo"`),
+			run: func(opts manager.Options) {
+				_, _ = opts.GenerateRequest(testMetadata())
+			},
+			expEvents: []string{
+				`Warning InvalidAttributes Failed to generate certificate request: undefined variable "Foo"`,
+			},
+		},
+		"policy denials should post a policy denied warning": {
+			generateRequestErr: &policy.DeniedError{Namespace: "my-namespace", Violations: []string{"CA certificates are not allowed"}},
+			run: func(opts manager.Options) {
+				_, _ = opts.GenerateRequest(testMetadata())
+			},
+			expEvents: []string{
+				`Warning PolicyDenied Failed to generate certificate request: request denied by issuance policy for namespace "my-namespace": CA certificates are not allowed`,
+			},
+		},
+		"first write should post an issued event": {
+			run: func(opts manager.Options) {
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+				_ = opts.WriteKeypair(testMetadata(), nil, nil, nil)
+			},
+			expEvents: []string{
+				"Normal Issued Issued certificate for volume vol-id",
+			},
+		},
+		"subsequent writes should post a renewed event": {
+			run: func(opts manager.Options) {
+				meta := testMetadata()
+				nextIssuanceTime := time.Now()
+				meta.NextIssuanceTime = &nextIssuanceTime
+				_, _ = opts.GeneratePrivateKey(meta)
+				_ = opts.WriteKeypair(meta, nil, nil, nil)
+			},
+			expEvents: []string{
+				"Normal Renewed Renewed certificate for volume vol-id",
+			},
+		},
+		"a retry after a denied request should post a denied warning": {
+			existing: []*cmapi.CertificateRequest{deniedRequest},
+			run: func(opts manager.Options) {
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+			},
+			expEvents: []string{
+				"Warning CertificateRequestDenied CertificateRequest my-request was denied: not allowed",
+			},
+		},
+		"a retry after a denied request for another volume of the pod should not post a warning": {
+			existing: []*cmapi.CertificateRequest{otherVolumeRequest},
+			run: func(opts manager.Options) {
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+			},
+			expEvents: nil,
+		},
+		"a retry after a successful write should not look up the previous request": {
+			existing: []*cmapi.CertificateRequest{deniedRequest},
+			run: func(opts manager.Options) {
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+				_ = opts.WriteKeypair(testMetadata(), nil, nil, nil)
+				_, _ = opts.GeneratePrivateKey(testMetadata())
+			},
+			expEvents: []string{
+				"Normal Issued Issued certificate for volume vol-id",
+			},
+		},
+		"gate reasons should only be posted when they change": {
+			gateReasons: []string{"pod has no ipv6 address yet", "pod has no ipv6 address yet", "other reason", ""},
+			run: func(opts manager.Options) {
+				for range 4 {
+					opts.ReadyToRequest(testMetadata())
+				}
+			},
+			expEvents: []string{
+				"Normal WaitingForReadinessGates Waiting to request certificate: pod has no ipv6 address yet",
+				"Normal WaitingForReadinessGates Waiting to request certificate: other reason",
+			},
+		},
+		"events without a pod in the volume context should be dropped": {
+			generateRequestErr: errors.New("error"),
+			run: func(opts manager.Options) {
+				_, _ = opts.GenerateRequest(metadata.Metadata{VolumeID: "vol-id"})
+			},
+			expEvents: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var objects []runtime.Object
+			for _, cr := range test.existing {
+				objects = append(objects, cr)
+			}
+			cmClient := cmfake.NewSimpleClientset(objects...)
+
+			fakeRecorder := record.NewFakeRecorder(10)
+
+			gateCalls := 0
+			opts := manager.Options{
+				GeneratePrivateKey: func(metadata.Metadata) (crypto.PrivateKey, error) { return nil, test.generatePrivateKeyErr },
+				GenerateRequest: func(metadata.Metadata) (*manager.CertificateRequestBundle, error) {
+					return nil, test.generateRequestErr
+				},
+				SignRequest: func(metadata.Metadata, crypto.PrivateKey, *x509.CertificateRequest) ([]byte, error) {
+					return nil, nil
+				},
+				WriteKeypair: func(metadata.Metadata, crypto.PrivateKey, []byte, []byte) error { return test.writeKeypairErr },
+				ReadyToRequest: func(metadata.Metadata) (bool, string) {
+					reason := test.gateReasons[gateCalls]
+					gateCalls++
+					return reason == "", reason
+				},
+			}
+
+			New(fakeRecorder, cmClient, nil).Instrument(&opts)
+			test.run(opts)
+
+			close(fakeRecorder.Events)
+			var gotEvents []string
+			for event := range fakeRecorder.Events {
+				gotEvents = append(gotEvents, event)
+			}
+			assert.Equal(t, test.expEvents, gotEvents)
+		})
+	}
+}
+
+func Test_InstrumentAnnotatesRequest(t *testing.T) {
+	opts := manager.Options{
+		GenerateRequest: func(metadata.Metadata) (*manager.CertificateRequestBundle, error) {
+			return &manager.CertificateRequestBundle{}, nil
+		},
+	}
+
+	New(record.NewFakeRecorder(10), nil, nil).Instrument(&opts)
+	bundle, err := opts.GenerateRequest(testMetadata())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{VolumeIDAnnotationKey: "vol-id"}, bundle.Annotations)
+}
+
+func Test_ReadinessGatesTimedOut(t *testing.T) {
+	tests := map[string]struct {
+		policy   readinessgate.TimeoutPolicy
+		expEvent string
+	}{
+		"the issue policy should report the certificate is requested anyway": {
+			policy:   readinessgate.TimeoutPolicyIssue,
+			expEvent: "Warning ReadinessGatesTimedOut Not ready to request certificate after 5m0s, requesting it without the pod IPs not yet assigned: pod has no ipv6 address yet",
+		},
+		"the fail policy should report the volume is given up on": {
+			policy:   readinessgate.TimeoutPolicyFail,
+			expEvent: "Warning ReadinessGatesTimedOut Not ready to request certificate after 5m0s, giving up: pod has no ipv6 address yet",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fakeRecorder := record.NewFakeRecorder(10)
+			New(fakeRecorder, nil, nil).ReadinessGatesTimedOut(testMetadata(), 5*time.Minute, test.policy, "pod has no ipv6 address yet")
+
+			close(fakeRecorder.Events)
+			var gotEvents []string
+			for event := range fakeRecorder.Events {
+				gotEvents = append(gotEvents, event)
+			}
+			assert.Equal(t, []string{test.expEvent}, gotEvents)
+		})
+	}
+}
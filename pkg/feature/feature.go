@@ -0,0 +1,56 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package feature contains the driver's feature gates, which are set with
+// the --feature-gates flag. The driver uses its own feature gate, rather than
+// cert-manager's, so that gates of the cert-manager libraries it imports are
+// not exposed.
+package feature
+
+import (
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+)
+
+// see https://kubernetes.io/docs/reference/command-line-tools-reference/feature-gates/#feature-stages
+
+const (
+	// MLDSAKeys allows volumes to request ML-DSA private keys with the
+	// csi.cert-manager.io/key-algorithm attribute set to "MLDSA". Few issuers
+	// support signing requests for ML-DSA keys yet. ML-DSA keys require the
+	// driver to be built with Go 1.27 or later.
+	MLDSAKeys featuregate.Feature = "MLDSAKeys"
+)
+
+var (
+	// DefaultMutableFeatureGate is a mutable version of DefaultFeatureGate.
+	// Only top-level commands and options setup should use it.
+	DefaultMutableFeatureGate featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
+
+	// DefaultFeatureGate is the shared global FeatureGate, used to check
+	// whether features are enabled.
+	DefaultFeatureGate featuregate.FeatureGate = DefaultMutableFeatureGate
+)
+
+func init() {
+	utilruntime.Must(DefaultMutableFeatureGate.Add(driverFeatureGates))
+}
+
+// driverFeatureGates defines all feature gates for the driver. To add a new
+// feature, define a key for it above and add it here.
+var driverFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	MLDSAKeys: {Default: false, PreRelease: featuregate.Alpha},
+}
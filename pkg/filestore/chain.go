@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filestore
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+// chainFiles adds the leaf certificate, intermediates and full chain files to
+// the given files, if their attributes are set.
+func chainFiles(attrs map[string]string, files map[string][]byte, chain, ca []byte) error {
+	leafFile := attrs[csiapi.LeafCertFileKey]
+	intermediatesFile := attrs[csiapi.IntermediatesFileKey]
+	fullChainFile := attrs[csiapi.FullChainFileKey]
+
+	if len(leafFile) == 0 && len(intermediatesFile) == 0 && len(fullChainFile) == 0 {
+		return nil
+	}
+
+	certs, err := pki.DecodeX509CertificateChainBytes(chain)
+	if err != nil {
+		return fmt.Errorf("decoding issued certificate chain: %w", err)
+	}
+
+	// Some issuers include the root in the issued chain, which should not be
+	// written as an intermediate.
+	var root *x509.Certificate
+	if last := certs[len(certs)-1]; len(certs) > 1 && isSelfSigned(last) {
+		root = last
+		certs = certs[:len(certs)-1]
+	}
+
+	if len(leafFile) > 0 {
+		files[leafFile] = encodeCertificates(certs[:1])
+	}
+
+	if len(intermediatesFile) > 0 {
+		files[intermediatesFile] = encodeCertificates(certs[1:])
+	}
+
+	if len(fullChainFile) > 0 {
+		// A self-signed issued certificate is its own root.
+		if last := certs[len(certs)-1]; root == nil && !isSelfSigned(last) {
+			root, err = findRoot(last, ca)
+			if err != nil {
+				return err
+			}
+		}
+		fullChain := certs[:len(certs):len(certs)]
+		if root != nil {
+			fullChain = append(fullChain, root)
+		}
+		files[fullChainFile] = encodeCertificates(fullChain)
+	}
+
+	return nil
+}
+
+// findRoot returns the self-signed certificate in the given CA PEM data which
+// signed the given certificate.
+func findRoot(cert *x509.Certificate, ca []byte) (*x509.Certificate, error) {
+	if len(ca) == 0 {
+		return nil, errors.New("no CA certificates to find the root certificate of the full chain in")
+	}
+
+	cas, err := pki.DecodeX509CertificateSetBytes(ca)
+	if err != nil {
+		return nil, fmt.Errorf("decoding CA certificates: %w", err)
+	}
+
+	for _, candidate := range cas {
+		if isSelfSigned(candidate) && cert.CheckSignatureFrom(candidate) == nil {
+			return candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no root certificate for issuer %q found in CA certificates", cert.Issuer)
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// encodeCertificates returns the given certificates PEM encoded, in order.
+func encodeCertificates(certs []*x509.Certificate) []byte {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		// Encoding to a bytes.Buffer cannot fail.
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filestore
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCertificate returns a certificate with the given common name, signed
+// by the given parent, or self-signed if parent is nil.
+func newTestCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		parent, parentKey = template, pk
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &pk.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, pk
+}
+
+func Test_chainFiles(t *testing.T) {
+	root, rootKey := newTestCertificate(t, "root", true, nil, nil)
+	intermediate, intermediateKey := newTestCertificate(t, "intermediate", true, root, rootKey)
+	leaf, _ := newTestCertificate(t, "leaf", false, intermediate, intermediateKey)
+	otherRoot, _ := newTestCertificate(t, "other-root", true, nil, nil)
+
+	pemOf := func(certs ...*x509.Certificate) []byte {
+		return encodeCertificates(certs)
+	}
+
+	allFiles := map[string]string{
+		"csi.cert-manager.io/leaf-certificate-file": "leaf.crt",
+		"csi.cert-manager.io/intermediates-file":    "intermediates.crt",
+		"csi.cert-manager.io/fullchain-file":        "fullchain.crt",
+	}
+
+	tests := map[string]struct {
+		attrs    map[string]string
+		chain    []byte
+		ca       []byte
+		expFiles map[string][]byte
+		expErr   bool
+	}{
+		"no attributes should write no files": {
+			attrs:    map[string]string{},
+			chain:    pemOf(leaf, intermediate),
+			ca:       pemOf(root),
+			expFiles: map[string][]byte{},
+		},
+		"a chain with an intermediate should be split, with the root from the CA": {
+			attrs: allFiles,
+			chain: pemOf(leaf, intermediate),
+			ca:    pemOf(otherRoot, root),
+			expFiles: map[string][]byte{
+				"leaf.crt":          pemOf(leaf),
+				"intermediates.crt": pemOf(intermediate),
+				"fullchain.crt":     pemOf(leaf, intermediate, root),
+			},
+		},
+		"a chain which includes the root should not write it as an intermediate": {
+			attrs: allFiles,
+			chain: pemOf(leaf, intermediate, root),
+			ca:    nil,
+			expFiles: map[string][]byte{
+				"leaf.crt":          pemOf(leaf),
+				"intermediates.crt": pemOf(intermediate),
+				"fullchain.crt":     pemOf(leaf, intermediate, root),
+			},
+		},
+		"a chain without intermediates should write an empty intermediates file": {
+			attrs: allFiles,
+			chain: pemOf(intermediate),
+			ca:    pemOf(root),
+			expFiles: map[string][]byte{
+				"leaf.crt":          pemOf(intermediate),
+				"intermediates.crt": nil,
+				"fullchain.crt":     pemOf(intermediate, root),
+			},
+		},
+		"a self-signed certificate should be its own root": {
+			attrs: allFiles,
+			chain: pemOf(root),
+			ca:    nil,
+			expFiles: map[string][]byte{
+				"leaf.crt":          pemOf(root),
+				"intermediates.crt": nil,
+				"fullchain.crt":     pemOf(root),
+			},
+		},
+		"only the leaf file should not require the root": {
+			attrs: map[string]string{
+				"csi.cert-manager.io/leaf-certificate-file": "leaf.crt",
+			},
+			chain: pemOf(leaf, intermediate),
+			ca:    nil,
+			expFiles: map[string][]byte{
+				"leaf.crt": pemOf(leaf),
+			},
+		},
+		"a full chain without the root in the CA should error": {
+			attrs:    allFiles,
+			chain:    pemOf(leaf, intermediate),
+			ca:       pemOf(otherRoot),
+			expFiles: map[string][]byte{},
+			expErr:   true,
+		},
+		"a full chain without any CA should error": {
+			attrs:    allFiles,
+			chain:    pemOf(leaf, intermediate),
+			ca:       nil,
+			expFiles: map[string][]byte{},
+			expErr:   true,
+		},
+		"an invalid chain should error": {
+			attrs:    allFiles,
+			chain:    []byte("not a chain"),
+			ca:       pemOf(root),
+			expFiles: map[string][]byte{},
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			files := make(map[string][]byte)
+			err := chainFiles(test.attrs, files, test.chain, test.ca)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			if err == nil {
+				assert.Equal(t, test.expFiles, files)
+			}
+		})
+	}
+}
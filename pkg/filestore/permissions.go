@@ -0,0 +1,94 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filestore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+// applyFilePermissions sets the mode and owner of the given files, written to
+// the given directory, according to the file mode and owner attributes. It is
+// a no-op if none of the attributes are set. Attributes are expected to have
+// been validated.
+func applyFilePermissions(dir string, attrs map[string]string, files map[string][]byte) error {
+	keyMode := attrs[csiapi.KeyFileModeKey]
+	certMode := attrs[csiapi.CertFileModeKey]
+	ownerUID := attrs[csiapi.FileOwnerUIDKey]
+
+	if len(keyMode) == 0 && len(certMode) == 0 && len(ownerUID) == 0 {
+		return nil
+	}
+
+	keyFiles := privateKeyFiles(attrs)
+
+	for name := range files {
+		path := filepath.Join(dir, name)
+
+		mode := certMode
+		if keyFiles[name] {
+			mode = keyMode
+		}
+		if len(mode) > 0 {
+			m, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil {
+				return fmt.Errorf("parsing file mode %q: %w", mode, err)
+			}
+			if err := os.Chmod(path, os.FileMode(m)); err != nil {
+				return err
+			}
+		}
+
+		if len(ownerUID) > 0 {
+			uid, err := strconv.Atoi(ownerUID)
+			if err != nil {
+				return fmt.Errorf("parsing file owner UID %q: %w", ownerUID, err)
+			}
+			// Keep the group, which may have been set by
+			// csi.cert-manager.io/fs-group.
+			if err := os.Chown(path, uid, -1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// privateKeyFiles returns the names of the files which contain the private
+// key, according to the attributes.
+func privateKeyFiles(attrs map[string]string) map[string]bool {
+	files := map[string]bool{
+		attrs[csiapi.KeyFileKey]: true,
+	}
+	for _, key := range []string{csiapi.KeyDERFileKey, csiapi.KeyURIFileKey, csiapi.CombinedPEMFileKey} {
+		if file := attrs[key]; len(file) > 0 {
+			files[file] = true
+		}
+	}
+	if attrs[csiapi.KeyStorePKCS12EnableKey] == "true" {
+		files[attrs[csiapi.KeyStorePKCS12FileKey]] = true
+	}
+	if attrs[csiapi.KeyStoreJKSEnableKey] == "true" {
+		files[attrs[csiapi.KeyStoreJKSFileKey]] = true
+	}
+	return files
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filestore
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyFilePermissions(t *testing.T) {
+	files := map[string][]byte{
+		"tls.key":      []byte("key"),
+		"tls.crt":      []byte("cert"),
+		"ca.crt":       []byte("ca"),
+		"keystore.p12": []byte("keystore"),
+		"tls.pem":      []byte("combined"),
+	}
+
+	baseAttrs := map[string]string{
+		"csi.cert-manager.io/privatekey-file":   "tls.key",
+		"csi.cert-manager.io/certificate-file":  "tls.crt",
+		"csi.cert-manager.io/ca-file":           "ca.crt",
+		"csi.cert-manager.io/combined-pem-file": "tls.pem",
+		"csi.cert-manager.io/pkcs12-enable":     "true",
+		"csi.cert-manager.io/pkcs12-filename":   "keystore.p12",
+	}
+
+	tests := map[string]struct {
+		attrs    map[string]string
+		expModes map[string]os.FileMode
+	}{
+		"no attributes should not change the file modes": {
+			attrs: map[string]string{},
+			expModes: map[string]os.FileMode{
+				"tls.key":      0o644,
+				"tls.crt":      0o644,
+				"ca.crt":       0o644,
+				"keystore.p12": 0o644,
+				"tls.pem":      0o644,
+			},
+		},
+		"the private key mode should apply to every file containing the key": {
+			attrs: map[string]string{
+				"csi.cert-manager.io/privatekey-file-mode": "0400",
+			},
+			expModes: map[string]os.FileMode{
+				"tls.key":      0o400,
+				"tls.crt":      0o644,
+				"ca.crt":       0o644,
+				"keystore.p12": 0o400,
+				"tls.pem":      0o400,
+			},
+		},
+		"the certificate mode should apply to every other file": {
+			attrs: map[string]string{
+				"csi.cert-manager.io/privatekey-file-mode":  "0400",
+				"csi.cert-manager.io/certificate-file-mode": "0444",
+			},
+			expModes: map[string]os.FileMode{
+				"tls.key":      0o400,
+				"tls.crt":      0o444,
+				"ca.crt":       0o444,
+				"keystore.p12": 0o400,
+				"tls.pem":      0o400,
+			},
+		},
+		"the owner UID should be applied without changing the modes": {
+			attrs: map[string]string{
+				"csi.cert-manager.io/file-owner-uid": strconv.Itoa(os.Getuid()),
+			},
+			expModes: map[string]os.FileMode{
+				"tls.key":      0o644,
+				"tls.crt":      0o644,
+				"ca.crt":       0o644,
+				"keystore.p12": 0o644,
+				"tls.pem":      0o644,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range files {
+				path := filepath.Join(dir, name)
+				require.NoError(t, os.WriteFile(path, data, 0o644))
+				// Ensure the mode is not affected by the umask.
+				require.NoError(t, os.Chmod(path, 0o644))
+			}
+
+			attrs := maps.Clone(baseAttrs)
+			maps.Copy(attrs, test.attrs)
+
+			require.NoError(t, applyFilePermissions(dir, attrs, files))
+
+			for name, expMode := range test.expModes {
+				info, err := os.Stat(filepath.Join(dir, name))
+				require.NoError(t, err)
+				assert.Equal(t, expMode, info.Mode().Perm(), name)
+
+				if uid, ok := attrs["csi.cert-manager.io/file-owner-uid"]; ok {
+					stat, ok := info.Sys().(*syscall.Stat_t)
+					require.True(t, ok)
+					assert.Equal(t, uid, strconv.Itoa(int(stat.Uid)), name)
+				}
+			}
+		})
+	}
+}
+
+func Test_applyFilePermissionsMissingFile(t *testing.T) {
+	err := applyFilePermissions(t.TempDir(), map[string]string{
+		"csi.cert-manager.io/privatekey-file":      "tls.key",
+		"csi.cert-manager.io/privatekey-file-mode": "0400",
+	}, map[string][]byte{"tls.key": []byte("key")})
+	assert.Error(t, err)
+}
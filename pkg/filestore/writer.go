@@ -28,30 +28,155 @@ import (
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/cert-manager/csi-lib/metadata"
 	"github.com/cert-manager/csi-lib/storage"
+	"github.com/youmark/pkcs8"
 
 	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
 	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+	"github.com/cert-manager/csi-driver/pkg/hsm"
+	"github.com/cert-manager/csi-driver/pkg/keystore/jks"
 	"github.com/cert-manager/csi-driver/pkg/keystore/pkcs12"
+	"github.com/cert-manager/csi-driver/pkg/secrets"
 )
 
+// encryptionOpts are the options used to encrypt PKCS#8 private keys, using
+// PBES2 with PBKDF2 and AES-256-CBC.
+var encryptionOpts = &pkcs8.Opts{
+	Cipher: pkcs8.AES256CBC,
+	KDFOpts: pkcs8.PBKDF2Opts{
+		SaltSize:       16,
+		IterationCount: 10000,
+		HMACHash:       crypto.SHA256,
+	},
+}
+
 // Writer wraps the storage backend to allow access for writing data.
 type Writer struct {
 	Store storage.Interface
+
+	// Secrets reads the passwords referenced by volume attributes. If nil,
+	// passwords may only be given directly as attributes.
+	Secrets *secrets.Getter
+
+	// HSM holds the private keys of volumes using the PKCS#11 key backend,
+	// and is used to delete their superseded keys.
+	HSM *hsm.Backend
+
+	// ClusterDefaults are the cluster-wide default attributes, layered under
+	// the attributes of each volume.
+	ClusterDefaults map[string]string
+
+	// Validation configures the validation of each volume's attributes.
+	Validation validation.Options
 }
 
 // WriteKeypair writes the given certificate, CA, and private key data to their
 // respective file locations, according to the volume attributes. Also writes
 // or updates the metadata file, including a calculated NextIssuanceTime.
 func (w *Writer) WriteKeypair(meta metadata.Metadata, key crypto.PrivateKey, chain []byte, ca []byte) error {
-	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext)
+	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext, w.ClusterDefaults)
 	if err != nil {
 		return err
 	}
-	if err := validation.ValidateAttributes(attrs); err != nil {
+	if err := validation.ValidateAttributes(attrs, w.Validation); err != nil {
 		return err.ToAggregate()
 	}
 
+	files := map[string][]byte{
+		attrs[csiapi.CertFileKey]: chain,
+		attrs[csiapi.CAFileKey]:   ca,
+	}
+
+	// Keys held in a PKCS#11 token never leave it, so only their URI is
+	// written. Validation ensures no other private key file is requested.
+	hsmKey, isHSMKey := key.(*hsm.Key)
+	if attrs[csiapi.KeyBackendKey] == csiapi.KeyBackendPKCS11 {
+		if !isHSMKey {
+			return errors.New("private key is not held in the PKCS#11 token")
+		}
+		files[attrs[csiapi.KeyURIFileKey]] = []byte(hsmKey.URI + "\n")
+	} else if err := w.keyFiles(attrs, files, key, chain); err != nil {
+		return err
+	}
+
+	if file := attrs[csiapi.CertDERFileKey]; len(file) > 0 {
+		ders := certificatesDER(chain)
+		if len(ders) == 0 {
+			return errors.New("encoding certificate DER file: no certificate found in the issued chain")
+		}
+		files[file] = ders[0]
+	}
+	if file := attrs[csiapi.CADERFileKey]; len(file) > 0 {
+		// Issuers such as ACME and Vault may not return a CA, in which case
+		// the CA DER file is written empty, as is the CA file. A DER file
+		// holds a single certificate, so the CA can't be truncated without
+		// losing trust in the certificates dropped.
+		switch ders := certificatesDER(ca); len(ders) {
+		case 0:
+			files[file] = []byte{}
+		case 1:
+			files[file] = ders[0]
+		default:
+			return fmt.Errorf("encoding CA DER file: the CA contains %d certificates, but a DER file may only hold one", len(ders))
+		}
+	}
+
+	// Handle leaf, intermediates and full chain attributes.
+	if err := chainFiles(attrs, files, chain, ca); err != nil {
+		return err
+	}
+
+	// Handle PKCS12 keystore and truststore attributes.
+	var pkcs12Password []byte
+	if attrs[csiapi.KeyStorePKCS12EnableKey] == "true" {
+		pkcs12Password, err = w.Secrets.Password(attrs, csiapi.KeyStorePKCS12PasswordKey, csiapi.KeyStorePKCS12PasswordSecretNameKey, csiapi.KeyStorePKCS12PasswordSecretKeyKey)
+		if err != nil {
+			return fmt.Errorf("reading pkcs12 password: %w", err)
+		}
+	}
+	if err := pkcs12.Handle(attrs, files, pkcs12Password, key, chain, ca); err != nil {
+		return err
+	}
+
+	// Handle JKS keystore and truststore attributes.
+	if err := jks.Handle(attrs, files, key, chain, ca); err != nil {
+		return err
+	}
+
+	// Calculate the next issuance time and check errors before writing files.
+	// This prevents cases where we write files but also have errors in the
+	// nextIssuanceTime, putting the volume into a bad state.
+	nextIssuanceTime, err := calculateNextIssuanceTime(attrs, chain)
+	if err != nil {
+		return fmt.Errorf("calculating next issuance time: %w", err)
+	}
+
+	if err := w.Store.WriteFiles(meta, files); err != nil {
+		return fmt.Errorf("writing data: %w", err)
+	}
+
+	// The storage backend writes files with its own mode, so any requested
+	// mode and owner are set once the files are written.
+	if err := applyFilePermissions(w.Store.PathForVolume(meta.VolumeID), attrs, files); err != nil {
+		return fmt.Errorf("applying file permissions: %w", err)
+	}
+
+	meta.NextIssuanceTime = &nextIssuanceTime
+	if err := w.Store.WriteMetadata(meta.VolumeID, meta); err != nil {
+		return fmt.Errorf("writing metadata: %w", err)
+	}
+
+	// The previous key is no longer referenced by the volume.
+	if isHSMKey && w.HSM != nil {
+		w.HSM.DeleteSupersededKeys(meta.VolumeID, hsmKey)
+	}
+
+	return nil
+}
+
+// keyFiles adds the private key file, and the combined PEM and private key
+// DER files if set, to files, encoding the key according to the attributes.
+func (w *Writer) keyFiles(attrs map[string]string, files map[string][]byte, key crypto.PrivateKey, chain []byte) error {
 	var pemBlock *pem.Block
 	switch keyEncodingFormat := attrs[csiapi.KeyEncodingKey]; keyEncodingFormat {
 	case string(cmapi.PKCS1):
@@ -64,6 +189,22 @@ func (w *Writer) WriteKeypair(meta metadata.Metadata, key crypto.PrivateKey, cha
 			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
 		}
 	case string(cmapi.PKCS8):
+		password, err := w.Secrets.Password(attrs, csiapi.KeyPasswordKey, csiapi.KeyPasswordSecretNameKey, csiapi.KeyPasswordSecretKeyKey)
+		if err != nil {
+			return fmt.Errorf("reading private key password: %w", err)
+		}
+		if password != nil {
+			bytes, err := pkcs8.MarshalPrivateKey(key, password, encryptionOpts)
+			if err != nil {
+				return fmt.Errorf("marshalling encrypted pkcs8 private key: %w", err)
+			}
+			pemBlock = &pem.Block{
+				Type:  "ENCRYPTED PRIVATE KEY",
+				Bytes: bytes,
+			}
+			break
+		}
+
 		bytes, err := x509.MarshalPKCS8PrivateKey(key)
 		if err != nil {
 			return fmt.Errorf("marshalling pkcs8 private key: %w", err)
@@ -77,36 +218,53 @@ func (w *Writer) WriteKeypair(meta metadata.Metadata, key crypto.PrivateKey, cha
 	}
 
 	keyPEM := pem.EncodeToMemory(pemBlock)
+	files[attrs[csiapi.KeyFileKey]] = keyPEM
 
-	files := map[string][]byte{
-		attrs[csiapi.KeyFileKey]:  keyPEM,
-		attrs[csiapi.CertFileKey]: chain,
-		attrs[csiapi.CAFileKey]:   ca,
+	if file := attrs[csiapi.CombinedPEMFileKey]; len(file) > 0 {
+		files[file] = combinedPEM(attrs[csiapi.CombinedPEMOrderKey], keyPEM, chain)
 	}
 
-	// Handle PKCS12 keystore attributes.
-	if err := pkcs12.Handle(attrs, files, key, chain); err != nil {
-		return err
+	// The private key DER file may only be used with the PKCS#8 encoding, so
+	// holds the PrivateKeyInfo, or the EncryptedPrivateKeyInfo if the key is
+	// encrypted, of the PEM private key file.
+	if file := attrs[csiapi.KeyDERFileKey]; len(file) > 0 {
+		files[file] = pemBlock.Bytes
 	}
 
-	// Calculate the next issuance time and check errors before writing files.
-	// This prevents cases where we write files but also have errors in the
-	// nextIssuanceTime, putting the volume into a bad state.
-	nextIssuanceTime, err := calculateNextIssuanceTime(attrs, chain)
-	if err != nil {
-		return fmt.Errorf("calculating next issuance time: %w", err)
-	}
+	return nil
+}
 
-	if err := w.Store.WriteFiles(meta, files); err != nil {
-		return fmt.Errorf("writing data: %w", err)
+// combinedPEM returns the private key and certificate chain PEM concatenated
+// in the given order. Key first is used if the order is empty.
+func combinedPEM(order string, keyPEM, chain []byte) []byte {
+	first, second := keyPEM, chain
+	if order == csiapi.CombinedPEMOrderCertFirst {
+		first, second = chain, keyPEM
 	}
 
-	meta.NextIssuanceTime = &nextIssuanceTime
-	if err := w.Store.WriteMetadata(meta.VolumeID, meta); err != nil {
-		return fmt.Errorf("writing metadata: %w", err)
+	combined := make([]byte, 0, len(first)+len(second)+1)
+	combined = append(combined, first...)
+	if len(combined) > 0 && combined[len(combined)-1] != '\n' {
+		combined = append(combined, '\n')
 	}
+	return append(combined, second...)
+}
 
-	return nil
+// certificatesDER returns the DER bytes of each certificate in the given PEM
+// data, in order.
+func certificatesDER(data []byte) [][]byte {
+	var ders [][]byte
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	return ders
 }
 
 // calculateNextIssuanceTime will return the time at when the certificate
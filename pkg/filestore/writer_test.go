@@ -17,19 +17,32 @@ limitations under the License.
 package filestore
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/cert-manager/csi-lib/metadata"
 	"github.com/cert-manager/csi-lib/storage"
+	keystore "github.com/pavlo-v-chernykh/keystore-go/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/youmark/pkcs8"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+	"github.com/cert-manager/csi-driver/pkg/hsm"
+	"github.com/cert-manager/csi-driver/pkg/secrets"
 )
 
 var (
@@ -101,6 +114,306 @@ func newTestBundle(t *testing.T, encoder keyEncoder) testBundle {
 	return testBundle{ca, caPEM, cert, certPEM, pk, pkPEM}
 }
 
+func Test_WriteKeypairPasswordSecrets(t *testing.T) {
+	bundle := newTestBundle(t, pkcs8Encoder)
+
+	client := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "passwords", Namespace: "my-namespace"},
+		Data: map[string][]byte{
+			"key":    []byte("key-password"),
+			"pkcs12": []byte("pkcs12-password"),
+		},
+	})
+
+	meta := metadata.Metadata{
+		VolumeID:   "vol-id",
+		TargetPath: "/target-path",
+		VolumeContext: map[string]string{
+			"csi.storage.k8s.io/pod.namespace":                    "my-namespace",
+			"csi.cert-manager.io/issuer-name":                     "ca-issuer",
+			"csi.cert-manager.io/key-encoding":                    "PKCS8",
+			"csi.cert-manager.io/privatekey-password-secret-name": "passwords",
+			"csi.cert-manager.io/privatekey-password-secret-key":  "key",
+			"csi.cert-manager.io/pkcs12-enable":                   "true",
+			"csi.cert-manager.io/pkcs12-password-secret-name":     "passwords",
+			"csi.cert-manager.io/pkcs12-password-secret-key":      "pkcs12",
+		},
+	}
+
+	store := storage.NewMemoryFS()
+	w := &Writer{
+		Store:      store,
+		Secrets:    &secrets.Getter{Client: client.CoreV1()},
+		Validation: validation.Options{SecretReferencesAllowed: true},
+	}
+
+	_, err := store.RegisterMetadata(meta)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteKeypair(meta, bundle.pk, bundle.certPEM, bundle.caPEM))
+
+	files, err := store.ReadFiles("vol-id")
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(files["tls.key"])
+	require.NotNil(t, block)
+	pk, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte("key-password"))
+	require.NoError(t, err)
+	assert.Equal(t, bundle.pk, pk)
+
+	pk, cert, _, err := pkcs12.DecodeChain(files["keystore.p12"], "pkcs12-password")
+	require.NoError(t, err)
+	assert.Equal(t, bundle.pk, pk)
+	assert.Equal(t, bundle.cert, cert)
+}
+
+func Test_WriteKeypairCADERFile(t *testing.T) {
+	bundle := newTestBundle(t, pkcs8Encoder)
+	other := newTestBundle(t, pkcs8Encoder)
+
+	tests := map[string]struct {
+		ca     []byte
+		expDER []byte
+		expErr bool
+	}{
+		"a single CA certificate should be written as DER": {
+			ca:     bundle.caPEM,
+			expDER: bundle.ca.Raw,
+		},
+		"an empty CA should be written as an empty DER file": {
+			ca:     nil,
+			expDER: []byte{},
+		},
+		"a CA with more than one certificate should error rather than be truncated": {
+			ca:     append(append([]byte{}, bundle.caPEM...), other.caPEM...),
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta := metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name": "ca-issuer",
+					"csi.cert-manager.io/ca-der-file": "ca.der",
+				},
+			}
+
+			store := storage.NewMemoryFS()
+			w := &Writer{Store: store}
+
+			_, err := store.RegisterMetadata(meta)
+			require.NoError(t, err)
+			werr := w.WriteKeypair(meta, bundle.pk, bundle.certPEM, test.ca)
+			require.Equal(t, test.expErr, werr != nil, "%v", werr)
+
+			files, err := store.ReadFiles("vol-id")
+			require.NoError(t, err)
+			assert.Equal(t, test.expDER, files["ca.der"])
+		})
+	}
+}
+
+func Test_WriteKeypairPKCS11(t *testing.T) {
+	bundle := newTestBundle(t, pkcs8Encoder)
+	key := &hsm.Key{Signer: bundle.pk, URI: "pkcs11:token=csi-driver;id=%01;type=private"}
+
+	tests := map[string]struct {
+		key      crypto.PrivateKey
+		expFiles map[string][]byte
+		expErr   bool
+	}{
+		"a PKCS#11 key should have only its URI written": {
+			key: key,
+			expFiles: map[string][]byte{
+				"ca.crt":      bundle.caPEM,
+				"tls.crt":     bundle.certPEM,
+				"tls.key.uri": []byte(key.URI + "\n"),
+			},
+		},
+		"a key not held in the token should error": {
+			key:    bundle.pk,
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta := metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":   "ca-issuer",
+					"csi.cert-manager.io/key-algorithm": "RSA",
+					"csi.cert-manager.io/key-backend":   "pkcs11",
+				},
+			}
+
+			store := storage.NewMemoryFS()
+			w := &Writer{Store: store}
+
+			_, err := store.RegisterMetadata(meta)
+			require.NoError(t, err)
+			err = w.WriteKeypair(meta, test.key, bundle.certPEM, bundle.caPEM)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			if test.expErr {
+				return
+			}
+
+			files, err := store.ReadFiles("vol-id")
+			require.NoError(t, err)
+			delete(files, "metadata.json")
+			assert.Equal(t, test.expFiles, files)
+		})
+	}
+}
+
+// dirStore is a storage backend whose volume files live in a directory, so
+// the files written by the driver may be inspected.
+type dirStore struct {
+	*storage.MemoryFS
+	dir string
+}
+
+func (d *dirStore) PathForVolume(volumeID string) string {
+	return filepath.Join(d.dir, volumeID, "data")
+}
+
+// WriteFiles replaces the files of the volume, as the atomic writer of csi-lib
+// does, so the modes of the previous files aren't kept.
+func (d *dirStore) WriteFiles(meta metadata.Metadata, files map[string][]byte) error {
+	dir := d.PathForVolume(meta.VolumeID)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return d.MemoryFS.WriteFiles(meta, files)
+}
+
+func Test_WriteKeypairFileModesOnRenewal(t *testing.T) {
+	bundle := newTestBundle(t, pkcs8Encoder)
+	renewed := newTestBundle(t, pkcs8Encoder)
+
+	meta := metadata.Metadata{
+		VolumeID:   "vol-id",
+		TargetPath: "/target-path",
+		VolumeContext: map[string]string{
+			"csi.cert-manager.io/issuer-name":           "ca-issuer",
+			"csi.cert-manager.io/privatekey-file-mode":  "0400",
+			"csi.cert-manager.io/certificate-file-mode": "0444",
+		},
+	}
+
+	store := &dirStore{MemoryFS: storage.NewMemoryFS(), dir: t.TempDir()}
+	w := &Writer{Store: store}
+
+	_, err := store.RegisterMetadata(meta)
+	require.NoError(t, err)
+
+	dir := store.PathForVolume(meta.VolumeID)
+	for _, b := range []testBundle{bundle, renewed} {
+		require.NoError(t, w.WriteKeypair(meta, b.pk, b.certPEM, b.caPEM))
+
+		for name, expMode := range map[string]os.FileMode{
+			"tls.key": 0o400,
+			"tls.crt": 0o444,
+			"ca.crt":  0o444,
+		} {
+			info, err := os.Stat(filepath.Join(dir, name))
+			require.NoError(t, err)
+			assert.Equal(t, expMode, info.Mode().Perm(), name)
+		}
+
+		crt, err := os.ReadFile(filepath.Join(dir, "tls.crt"))
+		require.NoError(t, err)
+		assert.Equal(t, b.certPEM, crt)
+	}
+}
+
+func Test_combinedPEM(t *testing.T) {
+	tests := map[string]struct {
+		order  string
+		keyPEM []byte
+		chain  []byte
+		expPEM []byte
+	}{
+		"empty order should write the key first": {
+			order:  "",
+			keyPEM: []byte("key\n"),
+			chain:  []byte("cert\n"),
+			expPEM: []byte("key\ncert\n"),
+		},
+		"key-first should write the key first": {
+			order:  "key-first",
+			keyPEM: []byte("key\n"),
+			chain:  []byte("cert\n"),
+			expPEM: []byte("key\ncert\n"),
+		},
+		"cert-first should write the chain first": {
+			order:  "cert-first",
+			keyPEM: []byte("key\n"),
+			chain:  []byte("cert\n"),
+			expPEM: []byte("cert\nkey\n"),
+		},
+		"a chain without a trailing newline should be separated from the key": {
+			order:  "cert-first",
+			keyPEM: []byte("key\n"),
+			chain:  []byte("cert"),
+			expPEM: []byte("cert\nkey\n"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expPEM, combinedPEM(test.order, test.keyPEM, test.chain))
+		})
+	}
+}
+
+func Test_certificatesDER(t *testing.T) {
+	bundle := newTestBundle(t, pkcs1Encoder)
+
+	tests := map[string]struct {
+		data   []byte
+		expDER [][]byte
+	}{
+		"a single certificate should return its DER": {
+			data:   bundle.certPEM,
+			expDER: [][]byte{bundle.cert.Raw},
+		},
+		"a chain should return the DER of each certificate in order": {
+			data:   append(append([]byte{}, bundle.certPEM...), bundle.caPEM...),
+			expDER: [][]byte{bundle.cert.Raw, bundle.ca.Raw},
+		},
+		"non-certificate blocks should be skipped": {
+			data:   append(append([]byte{}, bundle.pkPEM...), bundle.caPEM...),
+			expDER: [][]byte{bundle.ca.Raw},
+		},
+		"no certificates should return nothing": {
+			data:   bundle.pkPEM,
+			expDER: nil,
+		},
+		"empty data should return nothing": {
+			data:   nil,
+			expDER: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expDER, certificatesDER(test.data))
+		})
+	}
+}
+
 func Test_calculateNextIssuanceTime(t *testing.T) {
 	testBundle := newTestBundle(t, pkcs1Encoder)
 
@@ -150,6 +463,9 @@ func Test_WriteKeypair(t *testing.T) {
 	pkcs1Bundle := newTestBundle(t, pkcs1Encoder)
 	pkcs8Bundle := newTestBundle(t, pkcs8Encoder)
 
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(pkcs8Bundle.pk)
+	require.NoError(t, err)
+
 	tests := map[string]struct {
 		meta metadata.Metadata
 
@@ -257,6 +573,110 @@ func Test_WriteKeypair(t *testing.T) {
 			expErr: false,
 		},
 
+		"DER files should contain the leaf certificate, PKCS8 private key and CA": {
+			testBundle: pkcs8Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":          "ca-issuer",
+					"csi.cert-manager.io/key-encoding":         "PKCS8",
+					"csi.cert-manager.io/certificate-der-file": "tls.der",
+					"csi.cert-manager.io/privatekey-der-file":  "key.der",
+					"csi.cert-manager.io/ca-der-file":          "ca.der",
+				},
+			},
+			expFiles: map[string][]byte{
+				"ca.crt":  pkcs8Bundle.caPEM,
+				"tls.crt": pkcs8Bundle.certPEM,
+				"tls.key": pkcs8Bundle.pkPEM,
+				"ca.der":  pkcs8Bundle.ca.Raw,
+				"tls.der": pkcs8Bundle.cert.Raw,
+				"key.der": pkcs8DER,
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","nextIssuanceTime":"1970-01-03T00:00:00Z","volumeContext":{"csi.cert-manager.io/ca-der-file":"ca.der","csi.cert-manager.io/certificate-der-file":"tls.der","csi.cert-manager.io/issuer-name":"ca-issuer","csi.cert-manager.io/key-encoding":"PKCS8","csi.cert-manager.io/privatekey-der-file":"key.der"}}`,
+				),
+			},
+			expErr: false,
+		},
+		"private key DER file with PKCS1 encoding should error": {
+			testBundle: pkcs1Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":         "ca-issuer",
+					"csi.cert-manager.io/privatekey-der-file": "key.der",
+				},
+			},
+			expFiles: map[string][]byte{
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","volumeContext":{"csi.cert-manager.io/issuer-name":"ca-issuer","csi.cert-manager.io/privatekey-der-file":"key.der"}}`,
+				),
+			},
+			expErr: true,
+		},
+
+		"a private key password should write an encrypted PKCS8 private key": {
+			testBundle: pkcs8Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":         "ca-issuer",
+					"csi.cert-manager.io/key-encoding":        "PKCS8",
+					"csi.cert-manager.io/privatekey-password": "password",
+				},
+			},
+			expFiles: map[string][]byte{
+				"ca.crt":  pkcs8Bundle.caPEM,
+				"tls.crt": pkcs8Bundle.certPEM,
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","nextIssuanceTime":"1970-01-03T00:00:00Z","volumeContext":{"csi.cert-manager.io/issuer-name":"ca-issuer","csi.cert-manager.io/key-encoding":"PKCS8","csi.cert-manager.io/privatekey-password":"password"}}`,
+				),
+			},
+			expErr: false,
+		},
+		"a private key password should encrypt the private key DER file": {
+			testBundle: pkcs8Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":         "ca-issuer",
+					"csi.cert-manager.io/key-encoding":        "PKCS8",
+					"csi.cert-manager.io/privatekey-password": "password",
+					"csi.cert-manager.io/privatekey-der-file": "key.der",
+				},
+			},
+			expFiles: map[string][]byte{
+				"ca.crt":  pkcs8Bundle.caPEM,
+				"tls.crt": pkcs8Bundle.certPEM,
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","nextIssuanceTime":"1970-01-03T00:00:00Z","volumeContext":{"csi.cert-manager.io/issuer-name":"ca-issuer","csi.cert-manager.io/key-encoding":"PKCS8","csi.cert-manager.io/privatekey-der-file":"key.der","csi.cert-manager.io/privatekey-password":"password"}}`,
+				),
+			},
+			expErr: false,
+		},
+		"a private key password Secret without token requests should error": {
+			testBundle: pkcs8Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":                     "ca-issuer",
+					"csi.cert-manager.io/key-encoding":                    "PKCS8",
+					"csi.cert-manager.io/privatekey-password-secret-name": "key-password",
+				},
+			},
+			expFiles: map[string][]byte{
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","volumeContext":{"csi.cert-manager.io/issuer-name":"ca-issuer","csi.cert-manager.io/key-encoding":"PKCS8","csi.cert-manager.io/privatekey-password-secret-name":"key-password"}}`,
+				),
+			},
+			expErr: true,
+		},
+
 		"if encoder is unknown, return an error": {
 			testBundle: pkcs8Bundle,
 			meta: metadata.Metadata{
@@ -334,6 +754,44 @@ func Test_WriteKeypair(t *testing.T) {
 			},
 			expErr: false,
 		},
+		"combined PEM file should contain the key followed by the chain": {
+			testBundle: pkcs1Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":       "ca-issuer",
+					"csi.cert-manager.io/combined-pem-file": "tls.pem",
+				},
+			},
+			expFiles: map[string][]byte{
+				"ca.crt":  pkcs1Bundle.caPEM,
+				"tls.crt": pkcs1Bundle.certPEM,
+				"tls.key": pkcs1Bundle.pkPEM,
+				"tls.pem": append(append([]byte{}, pkcs1Bundle.pkPEM...), pkcs1Bundle.certPEM...),
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","nextIssuanceTime":"1970-01-03T00:00:00Z","volumeContext":{"csi.cert-manager.io/combined-pem-file":"tls.pem","csi.cert-manager.io/issuer-name":"ca-issuer"}}`,
+				),
+			},
+			expErr: false,
+		},
+		"combined PEM file which is a duplicate file path should error": {
+			testBundle: pkcs1Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":       "ca-issuer",
+					"csi.cert-manager.io/combined-pem-file": "tls.crt",
+				},
+			},
+			expFiles: map[string][]byte{
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","volumeContext":{"csi.cert-manager.io/combined-pem-file":"tls.crt","csi.cert-manager.io/issuer-name":"ca-issuer"}}`,
+				),
+			},
+			expErr: true,
+		},
 		"keystore PKCS12 with defined file and password": {
 			testBundle: pkcs8Bundle,
 			meta: metadata.Metadata{
@@ -399,6 +857,50 @@ func Test_WriteKeypair(t *testing.T) {
 			},
 			expErr: true,
 		},
+		"truststore PKCS12 with no file should default to truststore.p12": {
+			testBundle: pkcs8Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":                "ca-issuer",
+					"csi.cert-manager.io/key-encoding":               "PKCS8",
+					"csi.cert-manager.io/pkcs12-truststore-enable":   "true",
+					"csi.cert-manager.io/pkcs12-truststore-password": "my-password",
+				},
+			},
+			expFiles: map[string][]byte{
+				"ca.crt":  pkcs8Bundle.caPEM,
+				"tls.crt": pkcs8Bundle.certPEM,
+				"tls.key": pkcs8Bundle.pkPEM,
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","nextIssuanceTime":"1970-01-03T00:00:00Z","volumeContext":{"csi.cert-manager.io/issuer-name":"ca-issuer","csi.cert-manager.io/key-encoding":"PKCS8","csi.cert-manager.io/pkcs12-truststore-enable":"true","csi.cert-manager.io/pkcs12-truststore-password":"my-password"}}`,
+				),
+			},
+			expErr: false,
+		},
+		"keystore JKS with no file should default to keystore.jks": {
+			testBundle: pkcs8Bundle,
+			meta: metadata.Metadata{
+				VolumeID:   "vol-id",
+				TargetPath: "/target-path",
+				VolumeContext: map[string]string{
+					"csi.cert-manager.io/issuer-name":  "ca-issuer",
+					"csi.cert-manager.io/key-encoding": "PKCS8",
+					"csi.cert-manager.io/jks-enable":   "true",
+					"csi.cert-manager.io/jks-password": "my-password",
+				},
+			},
+			expFiles: map[string][]byte{
+				"ca.crt":  pkcs8Bundle.caPEM,
+				"tls.crt": pkcs8Bundle.certPEM,
+				"tls.key": pkcs8Bundle.pkPEM,
+				"metadata.json": []byte(
+					`{"volumeID":"vol-id","targetPath":"/target-path","nextIssuanceTime":"1970-01-03T00:00:00Z","volumeContext":{"csi.cert-manager.io/issuer-name":"ca-issuer","csi.cert-manager.io/jks-enable":"true","csi.cert-manager.io/jks-password":"my-password","csi.cert-manager.io/key-encoding":"PKCS8"}}`,
+				),
+			},
+			expErr: false,
+		},
 		"incorrect pkcs12 attribute should error": {
 			testBundle: pkcs8Bundle,
 			meta: metadata.Metadata{
@@ -424,7 +926,7 @@ func Test_WriteKeypair(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			store := storage.NewMemoryFS()
-			w := &Writer{store}
+			w := &Writer{Store: store}
 
 			_, err := w.Store.RegisterMetadata(test.meta)
 			assert.NoError(t, err)
@@ -455,6 +957,63 @@ func Test_WriteKeypair(t *testing.T) {
 				delete(files, pkcs12File)
 			}
 
+			// Only check pkcs12 truststore files if it has been enabled, and if
+			// there was no WriteKeypair error.
+			if test.meta.VolumeContext["csi.cert-manager.io/pkcs12-truststore-enable"] == "true" && werr == nil {
+				trustStoreFile := test.meta.VolumeContext["csi.cert-manager.io/pkcs12-truststore-filename"]
+				if trustStoreFile == "" {
+					trustStoreFile = "truststore.p12"
+				}
+
+				cas, err := pkcs12.DecodeTrustStore(files[trustStoreFile], test.meta.VolumeContext["csi.cert-manager.io/pkcs12-truststore-password"])
+				require.NoError(t, err)
+
+				assert.Equal(t, []*x509.Certificate{test.testBundle.ca}, cas)
+
+				// Delete the pksc12 truststore file to let the assertion for
+				// expFiles proceed.
+				delete(files, trustStoreFile)
+			}
+
+			// Only check encrypted private keys if a password has been given,
+			// and if there was no WriteKeypair error.
+			if password, ok := test.meta.VolumeContext["csi.cert-manager.io/privatekey-password"]; ok && werr == nil {
+				block, _ := pem.Decode(files["tls.key"])
+				require.NotNil(t, block)
+				assert.Equal(t, "ENCRYPTED PRIVATE KEY", block.Type)
+
+				pk, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+				require.NoError(t, err)
+				assert.Equal(t, test.testBundle.pk, pk)
+
+				// Delete the private key file to let the assertion for
+				// expFiles proceed.
+				delete(files, "tls.key")
+
+				if derFile := test.meta.VolumeContext["csi.cert-manager.io/privatekey-der-file"]; len(derFile) > 0 {
+					pk, err := pkcs8.ParsePKCS8PrivateKey(files[derFile], []byte(password))
+					require.NoError(t, err)
+					assert.Equal(t, test.testBundle.pk, pk)
+					delete(files, derFile)
+				}
+			}
+
+			// Only check JKS files if it has been enabled, and if there was no
+			// WriteKeypair error.
+			if test.meta.VolumeContext["csi.cert-manager.io/jks-enable"] == "true" && werr == nil {
+				ks := keystore.New()
+				require.NoError(t, ks.Load(bytes.NewReader(files["keystore.jks"]), []byte(test.meta.VolumeContext["csi.cert-manager.io/jks-password"])))
+
+				entry, err := ks.GetPrivateKeyEntry("certificate", []byte(test.meta.VolumeContext["csi.cert-manager.io/jks-password"]))
+				require.NoError(t, err)
+				pk, err := x509.ParsePKCS8PrivateKey(entry.PrivateKey)
+				require.NoError(t, err)
+				assert.Equal(t, test.testBundle.pk, pk)
+
+				// Delete the JKS file to let the assertion for expFiles proceed.
+				delete(files, "keystore.jks")
+			}
+
 			assert.Equal(t, test.expFiles, files)
 		})
 	}
@@ -0,0 +1,199 @@
+//go:build cgo
+
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsm
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ThalesGroup/crypto11"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+// Backend generates and finds private keys in a PKCS#11 token.
+type Backend struct {
+	log        logr.Logger
+	ctx        *crypto11.Context
+	tokenLabel string
+	nodeID     string
+}
+
+// New loads the configured PKCS#11 module, and logs in to the token. The
+// returned Backend must be closed once it is no longer used.
+func New(log logr.Logger, config Config) (*Backend, error) {
+	if len(config.NodeID) == 0 {
+		return nil, errors.New("node ID must be set")
+	}
+
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       config.ModulePath,
+		TokenLabel: config.TokenLabel,
+		Pin:        config.PIN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PKCS#11 token %q: %w", config.TokenLabel, err)
+	}
+
+	return &Backend{
+		log:        log,
+		ctx:        ctx,
+		tokenLabel: config.TokenLabel,
+		nodeID:     config.NodeID,
+	}, nil
+}
+
+// Close logs out of the token, and unloads the PKCS#11 module.
+func (b *Backend) Close() error {
+	return b.ctx.Close()
+}
+
+// GenerateKey generates a new non-exportable key pair in the token for the
+// given volume, according to the key algorithm and size attributes.
+// Attributes are expected to have been defaulted and validated.
+func (b *Backend) GenerateKey(volumeID string, attrs map[string]string) (*Key, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	label := volumeLabel(b.nodeID, volumeID)
+
+	size, err := strconv.Atoi(attrs[csiapi.KeySizeKey])
+	if err != nil {
+		return nil, err
+	}
+
+	var signer crypto11.Signer
+	switch attrs[csiapi.KeyAlgorithmKey] {
+	case string(cmapi.RSAKeyAlgorithm):
+		signer, err = b.ctx.GenerateRSAKeyPairWithLabel(id, label, size)
+	case string(cmapi.ECDSAKeyAlgorithm):
+		var curve elliptic.Curve
+		switch size {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported ECDSA key size %d", size)
+		}
+		signer, err = b.ctx.GenerateECDSAKeyPairWithLabel(id, label, curve)
+	default:
+		return nil, errUnsupportedAlgorithm
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generating key in PKCS#11 token: %w", err)
+	}
+
+	return &Key{Signer: signer, URI: keyURI(b.tokenLabel, id, label), id: id}, nil
+}
+
+// FindKey returns the key pair in the token with the given PKCS#11 URI.
+func (b *Backend) FindKey(uri string) (*Key, error) {
+	tokenLabel, id, err := parseKeyURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if tokenLabel != b.tokenLabel {
+		return nil, fmt.Errorf("key %q is not in token %q", uri, b.tokenLabel)
+	}
+
+	signer, err := b.ctx.FindKeyPair(id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("finding key in PKCS#11 token: %w", err)
+	}
+
+	return &Key{Signer: signer, URI: uri, id: id}, nil
+}
+
+// DeleteSupersededKeys deletes every key generated for the volume other than
+// the given key, e.g. the previous key once a renewed certificate has been
+// written. Errors are logged, since the keys are deleted once the volume is
+// removed anyway.
+func (b *Backend) DeleteSupersededKeys(volumeID string, key *Key) {
+	log := b.log.WithValues("volume_id", volumeID)
+
+	signers, err := b.ctx.FindKeyPairs(nil, volumeLabel(b.nodeID, volumeID))
+	if err != nil {
+		log.Error(err, "failed to find superseded keys")
+		return
+	}
+
+	for _, signer := range signers {
+		id, err := b.ctx.GetAttribute(signer, crypto11.CkaId)
+		if err != nil {
+			log.Error(err, "failed to read key id")
+			continue
+		}
+		if bytes.Equal(id.Value, key.id) {
+			continue
+		}
+		if err := signer.Delete(); err != nil {
+			log.Error(err, "failed to delete superseded key")
+		}
+	}
+}
+
+// DeleteOrphanedKeys deletes the keys generated on this node for volumes
+// which are not returned by listVolumes. Keys generated on other nodes sharing
+// the token are never deleted. Keys are listed before volumes, so that keys
+// generated for a volume published in the meantime are never deleted.
+func (b *Backend) DeleteOrphanedKeys(listVolumes func() ([]string, error)) error {
+	signers, err := b.ctx.FindAllKeyPairs()
+	if err != nil {
+		return fmt.Errorf("listing keys in PKCS#11 token: %w", err)
+	}
+
+	volumeIDs, err := listVolumes()
+	if err != nil {
+		return fmt.Errorf("listing volumes: %w", err)
+	}
+	present := make(map[string]struct{}, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		present[volumeID] = struct{}{}
+	}
+
+	for _, signer := range signers {
+		label, err := b.ctx.GetAttribute(signer, crypto11.CkaLabel)
+		if err != nil {
+			return fmt.Errorf("reading key label: %w", err)
+		}
+		volumeID, ok := volumeIDForLabel(b.nodeID, label.Value)
+		if !ok {
+			continue
+		}
+		if _, ok := present[volumeID]; ok {
+			continue
+		}
+		if err := signer.Delete(); err != nil {
+			return fmt.Errorf("deleting key of removed volume %q: %w", volumeID, err)
+		}
+		b.log.V(2).Info("deleted key of removed volume", "volume_id", volumeID)
+	}
+
+	return nil
+}
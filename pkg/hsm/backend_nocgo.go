@@ -0,0 +1,64 @@
+//go:build !cgo
+
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsm
+
+import (
+	"errors"
+
+	"github.com/go-logr/logr"
+)
+
+// errNoCgo is returned by every operation when the driver was built without
+// cgo, since the PKCS#11 module cannot be loaded.
+var errNoCgo = errors.New("the PKCS#11 key backend requires the driver to be built with cgo, which the released images are not")
+
+// Backend generates and finds private keys in a PKCS#11 token. It is not
+// supported by drivers built without cgo.
+type Backend struct {
+	log logr.Logger
+}
+
+// New returns an error, since the driver was built without cgo.
+func New(logr.Logger, Config) (*Backend, error) {
+	return nil, errNoCgo
+}
+
+// Close is a no-op.
+func (b *Backend) Close() error {
+	return nil
+}
+
+// GenerateKey returns an error, since the driver was built without cgo.
+func (b *Backend) GenerateKey(string, map[string]string) (*Key, error) {
+	return nil, errNoCgo
+}
+
+// FindKey returns an error, since the driver was built without cgo.
+func (b *Backend) FindKey(string) (*Key, error) {
+	return nil, errNoCgo
+}
+
+// DeleteSupersededKeys is a no-op.
+func (b *Backend) DeleteSupersededKeys(string, *Key) {}
+
+// DeleteOrphanedKeys returns an error, since the driver was built without
+// cgo.
+func (b *Backend) DeleteOrphanedKeys(func() ([]string, error)) error {
+	return errNoCgo
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hsm implements the PKCS#11 private key backend, which generates
+// non-exportable private keys in a PKCS#11 token, such as an HSM, and signs
+// requests with them in the token. Only the RFC 7512 PKCS#11 URI of each key
+// is written to the volume.
+//
+// The backend requires the driver to be built with cgo, so that the PKCS#11
+// module can be loaded. The released images are built without cgo, so the
+// backend is only available in custom builds.
+package hsm
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cert-manager/csi-lib/storage"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// labelPrefix prefixes the label of every key generated by the driver,
+	// followed by the ID of the node and the volume the key was generated for,
+	// separated by "/". Keys in the token without the prefix of the node are
+	// never deleted by the driver on that node, so a token may be shared by
+	// nodes.
+	labelPrefix = "csi.cert-manager.io/"
+
+	// garbageCollectionInterval is how often keys of removed volumes are
+	// deleted from the token.
+	garbageCollectionInterval = 5 * time.Minute
+)
+
+// Config selects the PKCS#11 module and token to use.
+type Config struct {
+	// ModulePath is the path to the PKCS#11 module shared library.
+	ModulePath string
+
+	// TokenLabel is the label of the token to generate keys in.
+	TokenLabel string
+
+	// PIN is the user PIN used to log in to the token.
+	PIN string
+
+	// NodeID is the ID of the node the driver is running on, which scopes the
+	// keys generated and deleted by the backend.
+	NodeID string
+}
+
+// Key is a private key held in a PKCS#11 token. It signs in the token, and
+// its private half cannot be read.
+type Key struct {
+	crypto.Signer
+
+	// URI is the RFC 7512 PKCS#11 URI of the key, which is written to the
+	// volume in place of the private key.
+	URI string
+
+	// id is the CKA_ID of the key in the token.
+	id []byte
+}
+
+// Run deletes the keys of volumes which have been removed from the given
+// store, periodically until the context is cancelled.
+func (b *Backend) Run(ctx context.Context, volumes storage.MetadataReader) {
+	wait.UntilWithContext(ctx, func(context.Context) {
+		if err := b.DeleteOrphanedKeys(volumes.ListVolumes); err != nil {
+			b.log.Error(err, "failed to delete keys of removed volumes")
+		}
+	}, garbageCollectionInterval)
+}
+
+// keyURI returns the RFC 7512 PKCS#11 URI of the private key with the given
+// ID and label, in the token with the given label.
+func keyURI(tokenLabel string, id, label []byte) string {
+	var encodedID strings.Builder
+	for _, b := range id {
+		fmt.Fprintf(&encodedID, "%%%02x", b)
+	}
+	return fmt.Sprintf("pkcs11:token=%s;object=%s;id=%s;type=private",
+		escape(tokenLabel), escape(string(label)), encodedID.String())
+}
+
+// parseKeyURI returns the token label and key ID of the given PKCS#11 URI,
+// as written by keyURI.
+func parseKeyURI(uri string) (string, []byte, error) {
+	path, ok := strings.CutPrefix(uri, "pkcs11:")
+	if !ok {
+		return "", nil, fmt.Errorf("invalid PKCS#11 URI %q: must begin with \"pkcs11:\"", uri)
+	}
+	// Query attributes, such as the module path, aren't used.
+	path, _, _ = strings.Cut(path, "?")
+
+	var tokenLabel string
+	var id []byte
+	for attr := range strings.SplitSeq(path, ";") {
+		name, value, _ := strings.Cut(attr, "=")
+		unescaped, err := url.PathUnescape(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid PKCS#11 URI %q: %w", uri, err)
+		}
+		switch name {
+		case "token":
+			tokenLabel = unescaped
+		case "id":
+			id = []byte(unescaped)
+		}
+	}
+
+	if len(id) == 0 {
+		return "", nil, fmt.Errorf("invalid PKCS#11 URI %q: no key id", uri)
+	}
+
+	return tokenLabel, id, nil
+}
+
+// escape percent-encodes every character of the given PKCS#11 URI attribute
+// value which is not unreserved.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// volumeLabel returns the label of keys generated on the given node for the
+// given volume.
+func volumeLabel(nodeID, volumeID string) []byte {
+	return []byte(labelPrefix + nodeID + "/" + volumeID)
+}
+
+// volumeIDForLabel returns the ID of the volume a key with the given label
+// was generated for, or false if the key was not generated by the driver on
+// the given node.
+func volumeIDForLabel(nodeID string, label []byte) (string, bool) {
+	volumeID, ok := strings.CutPrefix(string(label), labelPrefix+nodeID+"/")
+	return volumeID, ok && len(volumeID) > 0
+}
+
+// errUnsupportedAlgorithm is returned when a key is requested with an
+// algorithm the PKCS#11 backend doesn't support.
+var errUnsupportedAlgorithm = errors.New("only RSA and ECDSA keys are supported by the PKCS#11 key backend")
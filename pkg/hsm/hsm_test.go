@@ -0,0 +1,134 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_keyURI(t *testing.T) {
+	tests := map[string]struct {
+		tokenLabel string
+		id         []byte
+		label      []byte
+		expURI     string
+	}{
+		"a simple token label should not be escaped": {
+			tokenLabel: "csi-driver",
+			id:         []byte{0x01, 0xab},
+			label:      []byte("csi.cert-manager.io/node-1/vol-1"),
+			expURI:     "pkcs11:token=csi-driver;object=csi.cert-manager.io%2Fnode-1%2Fvol-1;id=%01%ab;type=private",
+		},
+		"reserved characters in the token label should be escaped": {
+			tokenLabel: "my token;1",
+			id:         []byte{0xff},
+			label:      []byte("csi.cert-manager.io/node-1/vol-1"),
+			expURI:     "pkcs11:token=my%20token%3B1;object=csi.cert-manager.io%2Fnode-1%2Fvol-1;id=%ff;type=private",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			uri := keyURI(test.tokenLabel, test.id, test.label)
+			assert.Equal(t, test.expURI, uri)
+
+			tokenLabel, id, err := parseKeyURI(uri)
+			assert.NoError(t, err)
+			assert.Equal(t, test.tokenLabel, tokenLabel)
+			assert.Equal(t, test.id, id)
+		})
+	}
+}
+
+func Test_parseKeyURI(t *testing.T) {
+	tests := map[string]struct {
+		uri           string
+		expTokenLabel string
+		expID         []byte
+		expErr        bool
+	}{
+		"a URI without the pkcs11 scheme should error": {
+			uri:    "file:token=csi-driver;id=%01",
+			expErr: true,
+		},
+		"a URI without an id should error": {
+			uri:    "pkcs11:token=csi-driver;object=foo",
+			expErr: true,
+		},
+		"a URI with an invalid escape should error": {
+			uri:    "pkcs11:token=csi-driver;id=%zz",
+			expErr: true,
+		},
+		"query attributes should be ignored": {
+			uri:           "pkcs11:token=csi-driver;id=%01%02?module-path=/usr/lib/libsofthsm2.so",
+			expTokenLabel: "csi-driver",
+			expID:         []byte{0x01, 0x02},
+		},
+		"a URI without a token should return an empty token label": {
+			uri:   "pkcs11:id=%01",
+			expID: []byte{0x01},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokenLabel, id, err := parseKeyURI(test.uri)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expTokenLabel, tokenLabel)
+			assert.Equal(t, test.expID, id)
+		})
+	}
+}
+
+func Test_volumeIDForLabel(t *testing.T) {
+	tests := map[string]struct {
+		label       string
+		expVolumeID string
+		expOK       bool
+	}{
+		"a label generated by the driver on the node should return the volume ID": {
+			label:       "csi.cert-manager.io/node-1/csi-abc123",
+			expVolumeID: "csi-abc123",
+			expOK:       true,
+		},
+		"a label generated by the driver on another node should not be a key of the node": {
+			label: "csi.cert-manager.io/node-10/csi-abc123",
+			expOK: false,
+		},
+		"a label without the prefix should not be a driver key": {
+			label: "some-other-key",
+			expOK: false,
+		},
+		"a label with only the node prefix should not be a driver key": {
+			label: "csi.cert-manager.io/node-1/",
+			expOK: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			volumeID, ok := volumeIDForLabel("node-1", []byte(test.label))
+			assert.Equal(t, test.expOK, ok)
+			if test.expOK {
+				assert.Equal(t, test.expVolumeID, volumeID)
+				assert.Equal(t, test.label, string(volumeLabel("node-1", volumeID)))
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+//go:build cgo
+
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsm
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+const (
+	softHSMTokenLabel = "csi-driver-test"
+	softHSMPIN        = "1234"
+)
+
+// softHSMModulePaths are the paths the SoftHSM v2 module is commonly
+// installed to, used when SOFTHSM2_MODULE is not set.
+var softHSMModulePaths = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// newSoftHSMToken initialises a new SoftHSM v2 token in a temporary
+// directory, and returns the path to the SoftHSM module. The test is skipped
+// if SoftHSM is not installed, unless SOFTHSM2_REQUIRED is "true".
+func newSoftHSMToken(t *testing.T) string {
+	t.Helper()
+
+	skip := t.Skip
+	if os.Getenv("SOFTHSM2_REQUIRED") == "true" {
+		skip = t.Fatal
+	}
+
+	modulePath := os.Getenv("SOFTHSM2_MODULE")
+	if len(modulePath) == 0 {
+		for _, path := range softHSMModulePaths {
+			if _, err := os.Stat(path); err == nil {
+				modulePath = path
+				break
+			}
+		}
+	}
+	if len(modulePath) == 0 {
+		skip("SoftHSM v2 module not found, set SOFTHSM2_MODULE to its path")
+	}
+	util, err := exec.LookPath("softhsm2-util")
+	if err != nil {
+		skip("softhsm2-util not found in PATH")
+	}
+
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	require.NoError(t, os.Mkdir(tokenDir, 0700))
+	confPath := filepath.Join(dir, "softhsm2.conf")
+	conf := fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\nlog.level = ERROR\n", tokenDir)
+	require.NoError(t, os.WriteFile(confPath, []byte(conf), 0600))
+	t.Setenv("SOFTHSM2_CONF", confPath)
+
+	out, err := exec.Command(util, "--init-token", "--free",
+		"--label", softHSMTokenLabel, "--pin", softHSMPIN, "--so-pin", softHSMPIN).CombinedOutput()
+	require.NoError(t, err, "initialising token: %s", out)
+
+	return modulePath
+}
+
+// newSoftHSMBackend returns a Backend for the SoftHSM token initialised by
+// newSoftHSMToken, for the given node.
+func newSoftHSMBackend(t *testing.T, modulePath, nodeID string) *Backend {
+	t.Helper()
+
+	b, err := New(logr.Discard(), Config{
+		ModulePath: modulePath,
+		TokenLabel: softHSMTokenLabel,
+		PIN:        softHSMPIN,
+		NodeID:     nodeID,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, b.Close())
+	})
+
+	return b
+}
+
+func Test_SoftHSMGenerateKey(t *testing.T) {
+	b := newSoftHSMBackend(t, newSoftHSMToken(t), "node-1")
+
+	tests := map[string]struct {
+		attrs  map[string]string
+		expErr bool
+	}{
+		"RSA 2048 should sign in the token": {
+			attrs: map[string]string{csiapi.KeyAlgorithmKey: "RSA", csiapi.KeySizeKey: "2048"},
+		},
+		"ECDSA 256 should sign in the token": {
+			attrs: map[string]string{csiapi.KeyAlgorithmKey: "ECDSA", csiapi.KeySizeKey: "256"},
+		},
+		"ECDSA with an unsupported size should error": {
+			attrs:  map[string]string{csiapi.KeyAlgorithmKey: "ECDSA", csiapi.KeySizeKey: "123"},
+			expErr: true,
+		},
+		"Ed25519 should error": {
+			attrs:  map[string]string{csiapi.KeyAlgorithmKey: "Ed25519"},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := b.GenerateKey("vol-"+name, test.attrs)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			if test.expErr {
+				return
+			}
+
+			csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+				Subject: pkix.Name{CommonName: "csi-driver-test"},
+			}, key)
+			require.NoError(t, err)
+			csr, err := x509.ParseCertificateRequest(csrDER)
+			require.NoError(t, err)
+			assert.NoError(t, csr.CheckSignature())
+
+			found, err := b.FindKey(key.URI)
+			require.NoError(t, err)
+			assert.Equal(t, key.URI, found.URI)
+			assert.Equal(t, key.Public(), found.Public())
+		})
+	}
+}
+
+func Test_SoftHSMDeleteKeys(t *testing.T) {
+	b := newSoftHSMBackend(t, newSoftHSMToken(t), "node-1")
+	attrs := map[string]string{csiapi.KeyAlgorithmKey: "ECDSA", csiapi.KeySizeKey: "256"}
+
+	previous, err := b.GenerateKey("vol-1", attrs)
+	require.NoError(t, err)
+	current, err := b.GenerateKey("vol-1", attrs)
+	require.NoError(t, err)
+	orphaned, err := b.GenerateKey("vol-2", attrs)
+	require.NoError(t, err)
+
+	// Only the previous key of the volume should be deleted.
+	b.DeleteSupersededKeys("vol-1", current)
+	_, err = b.FindKey(previous.URI)
+	assert.Error(t, err)
+	_, err = b.FindKey(current.URI)
+	assert.NoError(t, err)
+
+	// Only the keys of removed volumes should be deleted.
+	require.NoError(t, b.DeleteOrphanedKeys(func() ([]string, error) {
+		return []string{"vol-1"}, nil
+	}))
+	_, err = b.FindKey(current.URI)
+	assert.NoError(t, err)
+	_, err = b.FindKey(orphaned.URI)
+	assert.Error(t, err)
+
+	// Errors listing volumes should never delete keys.
+	require.Error(t, b.DeleteOrphanedKeys(func() ([]string, error) {
+		return nil, fmt.Errorf("failed")
+	}))
+	_, err = b.FindKey(current.URI)
+	assert.NoError(t, err)
+}
+
+func Test_SoftHSMSharedToken(t *testing.T) {
+	modulePath := newSoftHSMToken(t)
+	b1 := newSoftHSMBackend(t, modulePath, "node-1")
+	b2 := newSoftHSMBackend(t, modulePath, "node-2")
+	attrs := map[string]string{csiapi.KeyAlgorithmKey: "ECDSA", csiapi.KeySizeKey: "256"}
+
+	// Volume IDs are unique per node, so may be the same on both nodes.
+	key1, err := b1.GenerateKey("vol-1", attrs)
+	require.NoError(t, err)
+	key2, err := b2.GenerateKey("vol-1", attrs)
+	require.NoError(t, err)
+	orphaned2, err := b2.GenerateKey("vol-2", attrs)
+	require.NoError(t, err)
+
+	// Superseding a key on one node should not delete the key of the other.
+	current1, err := b1.GenerateKey("vol-1", attrs)
+	require.NoError(t, err)
+	b1.DeleteSupersededKeys("vol-1", current1)
+	_, err = b1.FindKey(key1.URI)
+	assert.Error(t, err)
+	_, err = b2.FindKey(key2.URI)
+	assert.NoError(t, err)
+
+	// A node with no volumes should not delete the keys of the other node.
+	require.NoError(t, b1.DeleteOrphanedKeys(func() ([]string, error) {
+		return nil, nil
+	}))
+	_, err = b1.FindKey(current1.URI)
+	assert.Error(t, err)
+	_, err = b2.FindKey(key2.URI)
+	assert.NoError(t, err)
+	_, err = b2.FindKey(orphaned2.URI)
+	assert.NoError(t, err)
+
+	// Only the keys of the removed volumes of the node should be deleted.
+	require.NoError(t, b2.DeleteOrphanedKeys(func() ([]string, error) {
+		return []string{"vol-1"}, nil
+	}))
+	_, err = b2.FindKey(key2.URI)
+	assert.NoError(t, err)
+	_, err = b2.FindKey(orphaned2.URI)
+	assert.Error(t, err)
+}
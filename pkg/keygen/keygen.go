@@ -23,6 +23,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"strconv"
@@ -32,54 +33,135 @@ import (
 	"github.com/cert-manager/cert-manager/pkg/util/pki"
 	"github.com/cert-manager/csi-lib/metadata"
 	"github.com/cert-manager/csi-lib/storage"
+	"github.com/youmark/pkcs8"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
 	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+	"github.com/cert-manager/csi-driver/pkg/hsm"
+	"github.com/cert-manager/csi-driver/pkg/secrets"
 )
 
 // Generator wraps the storage backend to allow for re-using private keys when
 // re-issuing a certificate.
 type Generator struct {
 	Store *storage.Filesystem
+
+	// Secrets reads the passwords referenced by volume attributes, used to
+	// decrypt encrypted private keys. If nil, passwords may only be given
+	// directly as attributes.
+	Secrets *secrets.Getter
+
+	// HSM generates the private keys of volumes using the PKCS#11 key
+	// backend. If nil, volumes may not use the PKCS#11 key backend.
+	HSM *hsm.Backend
+
+	// Pool holds pregenerated private keys, which are used in preference to
+	// generating new keys. If nil, keys are always generated on demand.
+	Pool *Pool
+
+	// ClusterDefaults are the cluster-wide default attributes, layered under
+	// the attributes of each volume.
+	ClusterDefaults map[string]string
+
+	// Validation configures the validation of each volume's attributes.
+	Validation validation.Options
 }
 
 // KeyForMetadata generates a new private key, or returns an existing
 // one if the reuse private key attribute is present.
 func (k *Generator) KeyForMetadata(meta metadata.Metadata) (crypto.PrivateKey, error) {
-	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext)
+	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext, k.ClusterDefaults)
 	if err != nil {
 		return nil, err
 	}
-	if err := validation.ValidateAttributes(attrs); err != nil {
+	if err := validation.ValidateAttributes(attrs, k.Validation); err != nil {
 		return nil, err.ToAggregate()
 	}
 
+	if attrs[csiapi.KeyBackendKey] == csiapi.KeyBackendPKCS11 {
+		return k.hsmKey(meta, attrs)
+	}
+
 	// By default, generate a new private key each time.
 	if attrs[csiapi.ReusePrivateKey] != "true" {
-		return newKey(attrs)
+		return k.newKey(attrs)
 	}
 
 	bytes, err := k.Store.ReadFile(meta.VolumeID, attrs[csiapi.KeyFileKey])
 	if errors.Is(err, storage.ErrNotFound) {
 		// Generate a new key if one is not found on disk
-		return newKey(attrs)
+		return k.newKey(attrs)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	pk, err := pki.DecodePrivateKeyBytes(bytes)
+	password, err := k.Secrets.Password(attrs, csiapi.KeyPasswordKey, csiapi.KeyPasswordSecretNameKey, csiapi.KeyPasswordSecretKeyKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key password: %w", err)
+	}
+
+	pk, err := decodePrivateKey(bytes, password)
 	if err != nil {
 		// Generate a new key if the existing one cannot be decoded
-		return newKey(attrs)
+		return k.newKey(attrs)
 	}
 
 	return pk, nil
 }
 
-func newKey(attrs map[string]string) (crypto.PrivateKey, error) {
+// hsmKey generates a new private key in the PKCS#11 token, or returns the
+// existing one referenced by the volume's private key URI file if the reuse
+// private key attribute is present.
+func (k *Generator) hsmKey(meta metadata.Metadata, attrs map[string]string) (crypto.PrivateKey, error) {
+	if k.HSM == nil {
+		return nil, fmt.Errorf("%q is %q, but the driver has no PKCS#11 module configured", csiapi.KeyBackendKey, csiapi.KeyBackendPKCS11)
+	}
+
+	if attrs[csiapi.ReusePrivateKey] == "true" {
+		uri, err := k.Store.ReadFile(meta.VolumeID, attrs[csiapi.KeyURIFileKey])
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			// Generate a new key if the existing one cannot be found
+			if key, err := k.HSM.FindKey(strings.TrimSpace(string(uri))); err == nil {
+				return key, nil
+			}
+		}
+	}
+
+	return k.HSM.GenerateKey(meta.VolumeID, attrs)
+}
+
+// decodePrivateKey decodes the given PEM encoded private key, decrypting it
+// with the given password if it is an encrypted PKCS#8 key.
+func decodePrivateKey(data, password []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		return pki.DecodePrivateKeyBytes(data)
+	}
+
+	if len(password) == 0 {
+		return nil, errors.New("private key is encrypted, but no password was given")
+	}
+
+	return pkcs8.ParsePKCS8PrivateKey(block.Bytes, password)
+}
+
+// newKey returns a private key for the given attributes from the pool, or
+// generates a new one if none is available.
+func (k *Generator) newKey(attrs map[string]string) (crypto.PrivateKey, error) {
+	if pk, ok := k.Pool.Get(attrs); ok {
+		return pk, nil
+	}
+	return generateKey(attrs)
+}
+
+// generateKey generates a new private key for the given attributes.
+func generateKey(attrs map[string]string) (crypto.PrivateKey, error) {
 	switch algo := attrs[csiapi.KeyAlgorithmKey]; algo {
 	case string(cmapi.RSAKeyAlgorithm):
 		size, err := strconv.Atoi(attrs[csiapi.KeySizeKey])
@@ -105,6 +187,8 @@ func newKey(attrs map[string]string) (crypto.PrivateKey, error) {
 	case string(cmapi.Ed25519KeyAlgorithm):
 		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
 		return privateKey, err
+	case csiapi.MLDSAKeyAlgorithm:
+		return newMLDSAKey(attrs[csiapi.KeySizeKey])
 	default:
 		validValues := []cmapi.PrivateKeyAlgorithm{cmapi.RSAKeyAlgorithm, cmapi.ECDSAKeyAlgorithm, cmapi.Ed25519KeyAlgorithm}
 		quotedValues := make([]string, len(validValues))
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keygen
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/youmark/pkcs8"
+)
+
+func Test_decodePrivateKey(t *testing.T) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	plainDER, err := x509.MarshalPKCS8PrivateKey(pk)
+	require.NoError(t, err)
+	plainPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: plainDER})
+
+	encryptedDER, err := pkcs8.MarshalPrivateKey(pk, []byte("password"), nil)
+	require.NoError(t, err)
+	encryptedPEM := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encryptedDER})
+
+	tests := map[string]struct {
+		data     []byte
+		password []byte
+		expErr   bool
+	}{
+		"an unencrypted key should be decoded": {
+			data:     plainPEM,
+			password: nil,
+		},
+		"an unencrypted key should be decoded, ignoring the password": {
+			data:     plainPEM,
+			password: []byte("password"),
+		},
+		"an encrypted key should be decrypted with the password": {
+			data:     encryptedPEM,
+			password: []byte("password"),
+		},
+		"an encrypted key with the wrong password should error": {
+			data:     encryptedPEM,
+			password: []byte("wrong"),
+			expErr:   true,
+		},
+		"an encrypted key without a password should error": {
+			data:     encryptedPEM,
+			password: nil,
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := decodePrivateKey(test.data, test.password)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			if !test.expErr {
+				assert.True(t, pk.Equal(key))
+			}
+		})
+	}
+}
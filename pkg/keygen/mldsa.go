@@ -0,0 +1,42 @@
+//go:build go1.27
+
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keygen
+
+import (
+	"crypto"
+	"crypto/mldsa"
+	"fmt"
+)
+
+// newMLDSAKey generates an ML-DSA private key with the parameter set given by
+// the key size: "44", "65" or "87".
+func newMLDSAKey(size string) (crypto.PrivateKey, error) {
+	var params mldsa.Parameters
+	switch size {
+	case "44":
+		params = mldsa.MLDSA44()
+	case "65":
+		params = mldsa.MLDSA65()
+	case "87":
+		params = mldsa.MLDSA87()
+	default:
+		return nil, fmt.Errorf("unsupported ML-DSA parameter set %q", size)
+	}
+	return mldsa.GenerateKey(params)
+}
@@ -0,0 +1,70 @@
+//go:build go1.27
+
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keygen
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newMLDSAKey(t *testing.T) {
+	tests := map[string]struct {
+		size   string
+		expErr bool
+	}{
+		"ML-DSA-44 should be generated": {size: "44"},
+		"ML-DSA-65 should be generated": {size: "65"},
+		"ML-DSA-87 should be generated": {size: "87"},
+		"an unsupported size should error": {
+			size:   "2048",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := newMLDSAKey(test.size)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			if test.expErr {
+				return
+			}
+
+			// The key must round trip through PKCS#8, as it is written to and
+			// read back from the volume, and be able to sign a request.
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			require.NoError(t, err)
+			decoded, err := decodePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil)
+			require.NoError(t, err)
+
+			csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+				Subject: pkix.Name{CommonName: "test"},
+			}, decoded)
+			require.NoError(t, err)
+			csr, err := x509.ParseCertificateRequest(csrDER)
+			require.NoError(t, err)
+			assert.NoError(t, csr.CheckSignature())
+		})
+	}
+}
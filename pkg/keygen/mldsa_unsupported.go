@@ -0,0 +1,30 @@
+//go:build !go1.27
+
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keygen
+
+import (
+	"crypto"
+	"errors"
+)
+
+// newMLDSAKey returns an error, since ML-DSA keys are only supported by the
+// Go standard library from Go 1.27.
+func newMLDSAKey(string) (crypto.PrivateKey, error) {
+	return nil, errors.New("ML-DSA keys require the driver to be built with Go 1.27 or later")
+}
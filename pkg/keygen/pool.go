@@ -0,0 +1,195 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keygen
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+)
+
+// poolRetryInterval is the time to wait before generating another key for a
+// pool after generation failed.
+const poolRetryInterval = 5 * time.Second
+
+// Pool holds private keys which are generated in the background ahead of
+// time, so that volumes requesting keys which are slow to generate, such as
+// RSA 4096, don't wait for them during pod startup. Each key is received from
+// a channel, so is only ever handed out once.
+type Pool struct {
+	log logr.Logger
+
+	// keys holds the pregenerated keys of each pool, keyed by poolKey.
+	keys map[string]chan crypto.PrivateKey
+
+	// attrs holds the defaulted key attributes of each pool, used to
+	// generate its keys.
+	attrs map[string]map[string]string
+
+	hits   *prometheus.CounterVec
+	misses *prometheus.CounterVec
+}
+
+// NewPool returns a Pool with a pool for each of the given specs, holding up
+// to the given number of keys. Each spec has the form "<algorithm>/<size>",
+// e.g. "RSA/4096", or "<algorithm>" for algorithms without a size, e.g.
+// "Ed25519". No keys are generated until Run is called.
+func NewPool(log logr.Logger, sizes map[string]int) (*Pool, error) {
+	p := &Pool{
+		log:   log,
+		keys:  make(map[string]chan crypto.PrivateKey, len(sizes)),
+		attrs: make(map[string]map[string]string, len(sizes)),
+
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "certmanager",
+			Subsystem: "csi_driver",
+			Name:      "key_pool_hits_total",
+			Help:      "The number of private keys taken from the pregenerated key pool.",
+		}, []string{"key_algorithm", "key_size"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "certmanager",
+			Subsystem: "csi_driver",
+			Name:      "key_pool_misses_total",
+			Help:      "The number of private keys generated on demand because the pregenerated key pool was empty.",
+		}, []string{"key_algorithm", "key_size"}),
+	}
+
+	specs := make([]string, 0, len(sizes))
+	for spec := range sizes {
+		specs = append(specs, spec)
+	}
+	sort.Strings(specs)
+
+	for _, spec := range specs {
+		size := sizes[spec]
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid key pool %q: size must be greater than 0, got %d", spec, size)
+		}
+
+		alg, keySize, _ := strings.Cut(spec, "/")
+		attrs, err := defaults.SetDefaultAttributes(map[string]string{
+			csiapi.KeyAlgorithmKey: alg,
+			csiapi.KeySizeKey:      keySize,
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key pool %q: %w", spec, err)
+		}
+		if el := validation.ValidateDefaultAttributes(attrs, validation.Options{}); len(el) > 0 {
+			return nil, fmt.Errorf("invalid key pool %q: %w", spec, el.ToAggregate())
+		}
+
+		key := poolKey(attrs)
+		if _, ok := p.keys[key]; ok {
+			return nil, fmt.Errorf("invalid key pool %q: duplicate of another pool", spec)
+		}
+		p.keys[key] = make(chan crypto.PrivateKey, size)
+		p.attrs[key] = attrs
+	}
+
+	return p, nil
+}
+
+// Register registers the pool hit and miss metrics with the given registerer.
+func (p *Pool) Register(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{p.hits, p.misses} {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run fills each pool in the background, refilling it as keys are taken,
+// until the context is cancelled.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for key := range p.keys {
+		wg.Go(func() {
+			p.fill(ctx, key)
+		})
+	}
+	wg.Wait()
+}
+
+// fill generates keys for the given pool until the context is cancelled,
+// blocking while the pool is full.
+func (p *Pool) fill(ctx context.Context, key string) {
+	log := p.log.WithValues("pool", key)
+	for {
+		pk, err := generateKey(p.attrs[key])
+		if err != nil {
+			log.Error(err, "failed to generate private key for pool")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(poolRetryInterval):
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case p.keys[key] <- pk:
+		}
+	}
+}
+
+// Get takes a pregenerated key for the given defaulted volume attributes
+// from the pool, if there is a pool for the key algorithm and size. Misses
+// are only counted for algorithms and sizes which have a pool. Get is safe
+// to call on a nil Pool, which never returns a key.
+func (p *Pool) Get(attrs map[string]string) (crypto.PrivateKey, bool) {
+	if p == nil {
+		return nil, false
+	}
+
+	keys, ok := p.keys[poolKey(attrs)]
+	if !ok {
+		return nil, false
+	}
+
+	labels := []string{attrs[csiapi.KeyAlgorithmKey], attrs[csiapi.KeySizeKey]}
+	select {
+	case pk := <-keys:
+		p.hits.WithLabelValues(labels...).Inc()
+		return pk, true
+	default:
+		p.misses.WithLabelValues(labels...).Inc()
+		return nil, false
+	}
+}
+
+// poolKey returns the key of the pool for the key algorithm and size of the
+// given defaulted volume attributes.
+func poolKey(attrs map[string]string) string {
+	if size := attrs[csiapi.KeySizeKey]; len(size) > 0 {
+		return attrs[csiapi.KeyAlgorithmKey] + "/" + size
+	}
+	return attrs[csiapi.KeyAlgorithmKey]
+}
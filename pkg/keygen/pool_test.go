@@ -0,0 +1,156 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keygen
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+func Test_NewPool(t *testing.T) {
+	tests := map[string]struct {
+		sizes   map[string]int
+		expKeys []string
+		expErr  bool
+	}{
+		"no sizes should create no pools": {
+			sizes:   nil,
+			expKeys: []string{},
+		},
+		"algorithms and sizes should be defaulted and normalized": {
+			sizes:   map[string]int{"rsa/4096": 2, "ECDSA": 4, "ed25519": 1},
+			expKeys: []string{"RSA/4096", "ECDSA/256", "Ed25519"},
+		},
+		"a size of zero should error": {
+			sizes:  map[string]int{"RSA/4096": 0},
+			expErr: true,
+		},
+		"an unknown algorithm should error": {
+			sizes:  map[string]int{"DSA/2048": 1},
+			expErr: true,
+		},
+		"an invalid key size should error": {
+			sizes:  map[string]int{"ECDSA/123": 1},
+			expErr: true,
+		},
+		"a size for Ed25519 should error": {
+			sizes:  map[string]int{"Ed25519/256": 1},
+			expErr: true,
+		},
+		"duplicate pools should error": {
+			sizes:  map[string]int{"ECDSA": 1, "ECDSA/256": 1},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewPool(logr.Discard(), test.sizes)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			if test.expErr {
+				return
+			}
+
+			keys := make([]string, 0, len(p.keys))
+			for key := range p.keys {
+				keys = append(keys, key)
+			}
+			assert.ElementsMatch(t, test.expKeys, keys)
+		})
+	}
+}
+
+func Test_PoolGet(t *testing.T) {
+	p, err := NewPool(logr.Discard(), map[string]int{"ECDSA/256": 2})
+	require.NoError(t, err)
+
+	ecdsaAttrs := map[string]string{csiapi.KeyAlgorithmKey: "ECDSA", csiapi.KeySizeKey: "256"}
+	rsaAttrs := map[string]string{csiapi.KeyAlgorithmKey: "RSA", csiapi.KeySizeKey: "2048"}
+
+	// An empty pool should miss.
+	_, ok := p.Get(ecdsaAttrs)
+	assert.False(t, ok)
+	assert.Equal(t, 1.0, testutil.ToFloat64(p.misses.WithLabelValues("ECDSA", "256")))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(p.keys["ECDSA/256"]) == 2
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// Keys should be taken from the pool, and never handed out twice.
+	first, ok := p.Get(ecdsaAttrs)
+	require.True(t, ok)
+	second, ok := p.Get(ecdsaAttrs)
+	require.True(t, ok)
+	assert.IsType(t, &ecdsa.PrivateKey{}, first)
+	assert.False(t, first.(*ecdsa.PrivateKey).Equal(second))
+	assert.Equal(t, 2.0, testutil.ToFloat64(p.hits.WithLabelValues("ECDSA", "256")))
+
+	// Algorithms and sizes without a pool should be neither hits nor misses.
+	_, ok = p.Get(rsaAttrs)
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, testutil.ToFloat64(p.misses.WithLabelValues("RSA", "2048")))
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("pool did not stop after the context was cancelled")
+	}
+}
+
+func Test_GeneratorNewKey(t *testing.T) {
+	attrs := map[string]string{csiapi.KeyAlgorithmKey: "Ed25519"}
+
+	_, pooled, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	p, err := NewPool(logr.Discard(), map[string]int{"Ed25519": 1})
+	require.NoError(t, err)
+	p.keys["Ed25519"] <- pooled
+
+	// Without a pool, a key should be generated.
+	pk, err := (&Generator{}).newKey(attrs)
+	require.NoError(t, err)
+	assert.False(t, pooled.Equal(pk))
+
+	// With a pool, the pooled key should be used.
+	g := &Generator{Pool: p}
+	pk, err = g.newKey(attrs)
+	require.NoError(t, err)
+	assert.True(t, pooled.Equal(pk))
+
+	// Once the pool is empty, a key should be generated.
+	pk, err = g.newKey(attrs)
+	require.NoError(t, err)
+	assert.False(t, pooled.Equal(pk))
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jks
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	keystore "github.com/pavlo-v-chernykh/keystore-go/v4"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+// caAlias is the alias of the first CA certificate in a JKS truststore,
+// matching cert-manager's Certificate truststores. Subsequent CA
+// certificates use the alias "ca-<n>".
+const caAlias = "ca"
+
+// Handle will handle JKS keystore and truststore options in the given Volume
+// attributes. If enabled, A JKS keystore file containing the private key and
+// certificate chain, and a JKS truststore file containing the CA
+// certificates, will be encoded and written to the given file store.
+func Handle(attributes map[string]string, files map[string][]byte, pk crypto.PrivateKey, chainPEM, caPEM []byte) error {
+	if attributes[csiapi.KeyStoreJKSEnableKey] == "true" {
+		jks, err := create(attributes[csiapi.KeyStoreJKSPasswordKey], attributes[csiapi.KeyStoreJKSAliasKey], pk, chainPEM)
+		if err != nil {
+			return fmt.Errorf("failed to create jks file: %w", err)
+		}
+
+		// Write JKS file to the file store.
+		files[attributes[csiapi.KeyStoreJKSFileKey]] = jks
+	}
+
+	if attributes[csiapi.TrustStoreJKSEnableKey] == "true" {
+		jks, err := createTrustStore(attributes[csiapi.TrustStoreJKSPasswordKey], caPEM)
+		if err != nil {
+			return fmt.Errorf("failed to create jks truststore file: %w", err)
+		}
+
+		// Write JKS truststore file to the file store.
+		files[attributes[csiapi.TrustStoreJKSFileKey]] = jks
+	}
+
+	return nil
+}
+
+// create combines the inputs to a single JKS keystore file, with the private
+// key and certificate chain stored under the given alias. Certificates must
+// be PEM encoded.
+func create(password, alias string, pk crypto.PrivateKey, chainPEM []byte) ([]byte, error) {
+	chain, err := pki.DecodeX509CertificateChainBytes(chainPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate chain: %w", err)
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("no certificates decoded in certificate chain")
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(pk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	ks := keystore.New()
+	if err := ks.SetPrivateKeyEntry(alias, keystore.PrivateKeyEntry{
+		CreationTime:     time.Now(),
+		PrivateKey:       keyDER,
+		CertificateChain: certificates(chain),
+	}, []byte(password)); err != nil {
+		return nil, fmt.Errorf("failed to add private key to the JKS file: %w", err)
+	}
+
+	return store(ks, password)
+}
+
+// createTrustStore encodes the given CA certificates to a single JKS
+// truststore file. Certificates must be PEM encoded.
+func createTrustStore(password string, caPEM []byte) ([]byte, error) {
+	cas, err := pki.DecodeX509CertificateChainBytes(caPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode CA certificates: %w", err)
+	}
+
+	if len(cas) == 0 {
+		return nil, errors.New("no certificates decoded in CA certificates")
+	}
+
+	ks := keystore.New()
+	for i, cert := range certificates(cas) {
+		alias := caAlias
+		if i > 0 {
+			alias = fmt.Sprintf("%s-%d", caAlias, i)
+		}
+		if err := ks.SetTrustedCertificateEntry(alias, keystore.TrustedCertificateEntry{
+			CreationTime: time.Now(),
+			Certificate:  cert,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to add CA certificate to the JKS truststore file: %w", err)
+		}
+	}
+
+	return store(ks, password)
+}
+
+func certificates(certs []*x509.Certificate) []keystore.Certificate {
+	out := make([]keystore.Certificate, 0, len(certs))
+	for _, cert := range certs {
+		out = append(out, keystore.Certificate{
+			Type:    "X509",
+			Content: cert.Raw,
+		})
+	}
+	return out
+}
+
+func store(ks keystore.KeyStore, password string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, []byte(password)); err != nil {
+		return nil, fmt.Errorf("failed to encode the JKS file: %w", err)
+	}
+	return buf.Bytes(), nil
+}
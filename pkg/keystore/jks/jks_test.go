@@ -0,0 +1,207 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jks
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"testing"
+
+	keystore "github.com/pavlo-v-chernykh/keystore-go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/csi-driver/test/unit"
+)
+
+func Test_Handle(t *testing.T) {
+	root := unit.MustCreateBundle(t, nil, "root")
+
+	tests := map[string]struct {
+		attributes map[string]string
+		pk         crypto.PrivateKey
+		chainPEM   []byte
+		expFiles   []string
+	}{
+		"if no JKS attributes provided, expect no files written": {
+			attributes: map[string]string{},
+			pk:         root.PK,
+			chainPEM:   root.PEM,
+			expFiles:   []string{},
+		},
+		"if JKS enabled with options, expect file written": {
+			attributes: map[string]string{
+				"csi.cert-manager.io/jks-enable":   "true",
+				"csi.cert-manager.io/jks-password": "my-password",
+				"csi.cert-manager.io/jks-filename": "crt.jks",
+				"csi.cert-manager.io/jks-alias":    "my-alias",
+			},
+			pk:       root.PK,
+			chainPEM: root.PEM,
+			expFiles: []string{"crt.jks"},
+		},
+		"if JKS keystore and truststore enabled, expect both files written": {
+			attributes: map[string]string{
+				"csi.cert-manager.io/jks-enable":              "true",
+				"csi.cert-manager.io/jks-password":            "my-password",
+				"csi.cert-manager.io/jks-filename":            "crt.jks",
+				"csi.cert-manager.io/jks-alias":               "my-alias",
+				"csi.cert-manager.io/jks-truststore-enable":   "true",
+				"csi.cert-manager.io/jks-truststore-password": "my-other-password",
+				"csi.cert-manager.io/jks-truststore-filename": "ca.jks",
+			},
+			pk:       root.PK,
+			chainPEM: root.PEM,
+			expFiles: []string{"crt.jks", "ca.jks"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			files := make(map[string][]byte)
+			err := Handle(test.attributes, files, test.pk, test.chainPEM, root.PEM)
+			assert.NoError(t, err)
+
+			var gotFiles []string
+			for k := range files {
+				gotFiles = append(gotFiles, k)
+			}
+			assert.ElementsMatch(t, test.expFiles, gotFiles)
+		})
+	}
+}
+
+func Test_create(t *testing.T) {
+	root := unit.MustCreateBundle(t, nil, "root")
+	int1 := unit.MustCreateBundle(t, root, "int1")
+	int2 := unit.MustCreateBundle(t, int1, "int2")
+
+	tests := map[string]struct {
+		pk       crypto.PrivateKey
+		chainPEM []byte
+		expChain []*x509.Certificate
+		expErr   bool
+	}{
+		"if chain is empty, then expect error": {
+			pk:       int2.PK,
+			chainPEM: []byte{},
+			expErr:   true,
+		},
+		"if chain contains single certificate, expect it is encoded": {
+			pk:       int2.PK,
+			chainPEM: int2.PEM,
+			expChain: []*x509.Certificate{int2.Cert},
+			expErr:   false,
+		},
+		"if chain contains multiple certificates, expect they are all encoded in order": {
+			pk:       int2.PK,
+			chainPEM: bytes.Join([][]byte{int2.PEM, int1.PEM, root.PEM}, []byte("\n")),
+			expChain: []*x509.Certificate{int2.Cert, int1.Cert, root.Cert},
+			expErr:   false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := create("test-password", "my-alias", test.pk, test.chainPEM)
+			require.Equal(t, test.expErr, err != nil, "%v", err)
+			if test.expErr {
+				return
+			}
+
+			ks := keystore.New()
+			require.NoError(t, ks.Load(bytes.NewReader(resp), []byte("test-password")))
+			assert.Equal(t, []string{"my-alias"}, ks.Aliases())
+
+			entry, err := ks.GetPrivateKeyEntry("my-alias", []byte("test-password"))
+			require.NoError(t, err)
+
+			pk, err := x509.ParsePKCS8PrivateKey(entry.PrivateKey)
+			require.NoError(t, err)
+			assert.Equal(t, test.pk, pk)
+
+			var chain []*x509.Certificate
+			for _, c := range entry.CertificateChain {
+				assert.Equal(t, "X509", c.Type)
+				cert, err := x509.ParseCertificate(c.Content)
+				require.NoError(t, err)
+				chain = append(chain, cert)
+			}
+			assert.Equal(t, test.expChain, chain)
+		})
+	}
+}
+
+func Test_create_wrongPassword(t *testing.T) {
+	root := unit.MustCreateBundle(t, nil, "root")
+
+	resp, err := create("test-password", "my-alias", root.PK, root.PEM)
+	require.NoError(t, err)
+
+	ks := keystore.New()
+	assert.Error(t, ks.Load(bytes.NewReader(resp), []byte("wrong-password")))
+}
+
+func Test_createTrustStore(t *testing.T) {
+	root1 := unit.MustCreateBundle(t, nil, "root1")
+	root2 := unit.MustCreateBundle(t, nil, "root2")
+
+	tests := map[string]struct {
+		caPEM  []byte
+		expCAs map[string]*x509.Certificate
+		expErr bool
+	}{
+		"if CA is empty, then expect error": {
+			caPEM:  []byte{},
+			expErr: true,
+		},
+		"if CA contains single certificate, expect it is encoded with the ca alias": {
+			caPEM:  root1.PEM,
+			expCAs: map[string]*x509.Certificate{"ca": root1.Cert},
+			expErr: false,
+		},
+		"if CA contains multiple certificates, expect they are all encoded with numbered aliases": {
+			caPEM:  bytes.Join([][]byte{root1.PEM, root2.PEM}, []byte("\n")),
+			expCAs: map[string]*x509.Certificate{"ca": root1.Cert, "ca-1": root2.Cert},
+			expErr: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := createTrustStore("test-password", test.caPEM)
+			require.Equal(t, test.expErr, err != nil, "%v", err)
+			if test.expErr {
+				return
+			}
+
+			ks := keystore.New()
+			require.NoError(t, ks.Load(bytes.NewReader(resp), []byte("test-password")))
+
+			cas := make(map[string]*x509.Certificate)
+			for _, alias := range ks.Aliases() {
+				entry, err := ks.GetTrustedCertificateEntry(alias)
+				require.NoError(t, err)
+				cert, err := x509.ParseCertificate(entry.Certificate.Content)
+				require.NoError(t, err)
+				cas[alias] = cert
+			}
+			assert.Equal(t, test.expCAs, cas)
+		})
+	}
+}
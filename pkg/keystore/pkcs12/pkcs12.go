@@ -21,35 +21,54 @@ import (
 	"errors"
 	"fmt"
 
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/cert-manager/cert-manager/pkg/util/pki"
 	"software.sslmate.com/src/go-pkcs12"
 
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
 )
 
-// Handle will handle PKCS12 keystore options in the given Volume attributes.
-// If enabled, A PKCS12 keystore file will be encoded and written to the given
-// file store.
-func Handle(attributes map[string]string, files map[string][]byte, pk crypto.PrivateKey, chainPEM []byte) error {
-	// If PKCS12 support is not enabled, return early.
-	if attributes[csiapi.KeyStorePKCS12EnableKey] != "true" {
-		return nil
-	}
+// Handle will handle PKCS12 keystore and truststore options in the given
+// Volume attributes. If enabled, A PKCS12 keystore file containing the
+// private key and certificate chain, and a PKCS12 truststore file containing
+// the CA certificates, will be encoded and written to the given file store.
+// The keystore is encrypted with the given password, which may have been read
+// from a Secret rather than given by the attributes.
+func Handle(attributes map[string]string, files map[string][]byte, password []byte, pk crypto.PrivateKey, chainPEM, caPEM []byte) error {
+	profile := cmapi.PKCS12Profile(attributes[csiapi.KeyStorePKCS12ProfileKey])
 
-	pfx, err := create(attributes[csiapi.KeyStorePKCS12PasswordKey], pk, chainPEM)
-	if err != nil {
-		return fmt.Errorf("failed to create pkcs12 file: %w", err)
+	if attributes[csiapi.KeyStorePKCS12EnableKey] == "true" {
+		pfx, err := create(string(password), profile, pk, chainPEM)
+		if err != nil {
+			return fmt.Errorf("failed to create pkcs12 file: %w", err)
+		}
+
+		// Write PKCS12 file to the file store.
+		files[attributes[csiapi.KeyStorePKCS12FileKey]] = pfx
 	}
 
-	// Write PKCS12 file to the file store.
-	files[attributes[csiapi.KeyStorePKCS12FileKey]] = pfx
+	if attributes[csiapi.TrustStorePKCS12EnableKey] == "true" {
+		pfx, err := createTrustStore(attributes[csiapi.TrustStorePKCS12PasswordKey], profile, caPEM)
+		if err != nil {
+			return fmt.Errorf("failed to create pkcs12 truststore file: %w", err)
+		}
+
+		// Write PKCS12 truststore file to the file store.
+		files[attributes[csiapi.TrustStorePKCS12FileKey]] = pfx
+	}
 
 	return nil
 }
 
-// create combines the inputs to a single PKCS12 keystore file. Private key
-// must be PKCS1 or PKCS8 encoded. Certificates must be PEM encoded.
-func create(password string, pk crypto.PrivateKey, chainPEM []byte) ([]byte, error) {
+// create combines the inputs to a single PKCS12 keystore file, encrypted
+// using the algorithms of the given profile. Private key must be PKCS1 or
+// PKCS8 encoded. Certificates must be PEM encoded.
+func create(password string, profile cmapi.PKCS12Profile, pk crypto.PrivateKey, chainPEM []byte) ([]byte, error) {
+	encoder, err := encoderForProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
 	chain, err := pki.DecodeX509CertificateChainBytes(chainPEM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode certificate chain: %w", err)
@@ -59,10 +78,50 @@ func create(password string, pk crypto.PrivateKey, chainPEM []byte) ([]byte, err
 		return nil, errors.New("no certificates decoded in certificate chain")
 	}
 
-	pfx, err := pkcs12.LegacyRC2.Encode(pk, chain[0], chain[1:], password)
+	pfx, err := encoder.Encode(pk, chain[0], chain[1:], password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode the PKCS12 certificate chain file: %v", err)
 	}
 
 	return pfx, nil
 }
+
+// createTrustStore encodes the given CA certificates to a single PKCS12
+// truststore file. Certificates must be PEM encoded.
+func createTrustStore(password string, profile cmapi.PKCS12Profile, caPEM []byte) ([]byte, error) {
+	encoder, err := encoderForProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	cas, err := pki.DecodeX509CertificateChainBytes(caPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode CA certificates: %w", err)
+	}
+
+	if len(cas) == 0 {
+		return nil, errors.New("no certificates decoded in CA certificates")
+	}
+
+	pfx, err := encoder.EncodeTrustStore(cas, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the PKCS12 truststore file: %v", err)
+	}
+
+	return pfx, nil
+}
+
+// encoderForProfile returns the PKCS12 encoder for the given profile. An
+// empty profile uses LegacyRC2, matching cert-manager's Certificate keystores.
+func encoderForProfile(profile cmapi.PKCS12Profile) (*pkcs12.Encoder, error) {
+	switch profile {
+	case "", cmapi.LegacyRC2PKCS12Profile:
+		return pkcs12.LegacyRC2, nil
+	case cmapi.LegacyDESPKCS12Profile:
+		return pkcs12.LegacyDES, nil
+	case cmapi.Modern2023PKCS12Profile:
+		return pkcs12.Modern2023, nil
+	default:
+		return nil, fmt.Errorf("unsupported PKCS12 profile %q", profile)
+	}
+}
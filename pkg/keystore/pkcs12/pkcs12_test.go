@@ -22,6 +22,7 @@ import (
 	"crypto/x509"
 	"testing"
 
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"software.sslmate.com/src/go-pkcs12"
@@ -46,6 +47,43 @@ func Test_Handle(t *testing.T) {
 			expFiles:   []string{},
 			expErr:     false,
 		},
+		"if PKCS12 truststore enabled with options, expect file written": {
+			attributes: map[string]string{
+				"csi.cert-manager.io/pkcs12-truststore-enable":   "true",
+				"csi.cert-manager.io/pkcs12-truststore-password": "my-password",
+				"csi.cert-manager.io/pkcs12-truststore-filename": "ca.p12",
+			},
+			pk:       root.PK,
+			chainPEM: root.PEM,
+			expFiles: []string{"ca.p12"},
+			expErr:   false,
+		},
+		"if PKCS12 keystore and truststore enabled, expect both files written": {
+			attributes: map[string]string{
+				"csi.cert-manager.io/pkcs12-enable":              "true",
+				"csi.cert-manager.io/pkcs12-password":            "my-password",
+				"csi.cert-manager.io/pkcs12-filename":            "crt.p12",
+				"csi.cert-manager.io/pkcs12-truststore-enable":   "true",
+				"csi.cert-manager.io/pkcs12-truststore-password": "my-other-password",
+				"csi.cert-manager.io/pkcs12-truststore-filename": "ca.p12",
+			},
+			pk:       root.PK,
+			chainPEM: root.PEM,
+			expFiles: []string{"crt.p12", "ca.p12"},
+			expErr:   false,
+		},
+		"if PKCS12 enabled with a profile, expect file written": {
+			attributes: map[string]string{
+				"csi.cert-manager.io/pkcs12-enable":   "true",
+				"csi.cert-manager.io/pkcs12-password": "my-password",
+				"csi.cert-manager.io/pkcs12-filename": "crt.p12",
+				"csi.cert-manager.io/pkcs12-profile":  "Modern2023",
+			},
+			pk:       root.PK,
+			chainPEM: root.PEM,
+			expFiles: []string{"crt.p12"},
+			expErr:   false,
+		},
 		"if PKCS12 enabled with options, expect file written": {
 			attributes: map[string]string{
 				"csi.cert-manager.io/pkcs12-enable":   "true",
@@ -62,7 +100,8 @@ func Test_Handle(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			files := make(map[string][]byte)
-			err := Handle(test.attributes, files, test.pk, test.chainPEM)
+			password := []byte(test.attributes["csi.cert-manager.io/pkcs12-password"])
+			err := Handle(test.attributes, files, password, test.pk, test.chainPEM, root.PEM)
 			assert.NoError(t, err)
 
 			var gotFiles []string
@@ -113,19 +152,104 @@ func Test_create(t *testing.T) {
 		},
 	}
 
+	for name, test := range tests {
+		for _, profile := range []cmapi.PKCS12Profile{"", cmapi.LegacyRC2PKCS12Profile, cmapi.LegacyDESPKCS12Profile, cmapi.Modern2023PKCS12Profile} {
+			t.Run(name+"/"+string(profile), func(t *testing.T) {
+				resp, err := create("test-password", profile, test.pk, test.chainPEM)
+				require.Equal(t, test.expErr, err != nil, "%v", err)
+
+				if !test.expErr {
+					pk, cert, cas, err := pkcs12.DecodeChain(resp, "test-password")
+					require.NoError(t, err)
+
+					assert.Equal(t, test.expPK, pk)
+					assert.Equal(t, test.expCert, cert)
+					assert.Equal(t, test.expCAs, cas)
+				}
+			})
+		}
+	}
+}
+
+func Test_createProfile(t *testing.T) {
+	root := unit.MustCreateBundle(t, nil, "root")
+
+	// DER encoded object identifier of PBES2, which the Modern2023 profile
+	// uses to encrypt with AES-256, and the legacy profiles don't use.
+	oidPBES2 := []byte{0x06, 0x09, 0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x05, 0x0d}
+
+	tests := map[string]struct {
+		profile  cmapi.PKCS12Profile
+		expPBES2 bool
+		expErr   bool
+	}{
+		"LegacyRC2 should not use PBES2": {
+			profile:  cmapi.LegacyRC2PKCS12Profile,
+			expPBES2: false,
+		},
+		"LegacyDES should not use PBES2": {
+			profile:  cmapi.LegacyDESPKCS12Profile,
+			expPBES2: false,
+		},
+		"Modern2023 should use PBES2": {
+			profile:  cmapi.Modern2023PKCS12Profile,
+			expPBES2: true,
+		},
+		"an unknown profile should error": {
+			profile: "Modern1999",
+			expErr:  true,
+		},
+	}
+
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			resp, err := create("test-password", test.pk, test.chainPEM)
+			resp, err := create("test-password", test.profile, root.PK, root.PEM)
 			require.Equal(t, test.expErr, err != nil, "%v", err)
-
 			if !test.expErr {
-				pk, cert, cas, err := pkcs12.DecodeChain(resp, "test-password")
-				require.NoError(t, err)
-
-				assert.Equal(t, test.expPK, pk)
-				assert.Equal(t, test.expCert, cert)
-				assert.Equal(t, test.expCAs, cas)
+				assert.Equal(t, test.expPBES2, bytes.Contains(resp, oidPBES2))
 			}
 		})
 	}
 }
+
+func Test_createTrustStore(t *testing.T) {
+	root1 := unit.MustCreateBundle(t, nil, "root1")
+	root2 := unit.MustCreateBundle(t, nil, "root2")
+
+	tests := map[string]struct {
+		caPEM  []byte
+		expCAs []*x509.Certificate
+		expErr bool
+	}{
+		"if CA is empty, then expect error": {
+			caPEM:  []byte{},
+			expCAs: nil,
+			expErr: true,
+		},
+		"if CA contains single certificate, expect it is encoded": {
+			caPEM:  root1.PEM,
+			expCAs: []*x509.Certificate{root1.Cert},
+			expErr: false,
+		},
+		"if CA contains multiple certificates, expect they are all encoded": {
+			caPEM:  bytes.Join([][]byte{root1.PEM, root2.PEM}, []byte("\n")),
+			expCAs: []*x509.Certificate{root1.Cert, root2.Cert},
+			expErr: false,
+		},
+	}
+
+	for name, test := range tests {
+		for _, profile := range []cmapi.PKCS12Profile{cmapi.LegacyRC2PKCS12Profile, cmapi.LegacyDESPKCS12Profile, cmapi.Modern2023PKCS12Profile} {
+			t.Run(name+"/"+string(profile), func(t *testing.T) {
+				resp, err := createTrustStore("test-password", profile, test.caPEM)
+				require.Equal(t, test.expErr, err != nil, "%v", err)
+
+				if !test.expErr {
+					cas, err := pkcs12.DecodeTrustStore(resp, "test-password")
+					require.NoError(t, err)
+					assert.ElementsMatch(t, test.expCAs, cas)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics provides the Prometheus collectors owned by csi-driver,
+// describing the lifecycle of the certificates it manages for each volume.
+//
+// Issuance counters and latency are recorded by wrapping the functions passed
+// to csi-lib's manager.Options (see Metrics.Instrument), so no changes are
+// needed in csi-lib itself. Per-volume gauges are computed at scrape time from
+// the volumes present in the storage backend, so they disappear as soon as a
+// volume is unpublished.
+package metrics
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/csi-lib/manager"
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/cert-manager/csi-lib/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/utils/clock"
+
+	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate"
+)
+
+const (
+	namespace = "certmanager"
+	subsystem = "csi_driver"
+)
+
+var (
+	// issuanceLabels are the labels attached to the issuance counters and
+	// latency histogram.
+	issuanceLabels = []string{"namespace", "issuer_name", "issuer_kind", "issuer_group"}
+
+	// readinessGateTimeoutLabels are the labels attached to the readiness
+	// gate timeout counter.
+	readinessGateTimeoutLabels = []string{"namespace", "issuer_name", "issuer_kind", "issuer_group", "policy"}
+
+	// volumeLabels are the labels attached to the per-volume gauges.
+	volumeLabels = []string{"volume_id", "namespace", "pod", "issuer_name", "issuer_kind", "issuer_group"}
+)
+
+// VolumeReader is the subset of the storage backend needed to compute the
+// per-volume gauges. It is satisfied by *storage.Filesystem.
+type VolumeReader interface {
+	storage.MetadataReader
+
+	// ReadFile reads the named file from the given volume's data directory.
+	ReadFile(volumeID, name string) ([]byte, error)
+}
+
+// Metrics holds the driver-owned Prometheus collectors.
+type Metrics struct {
+	store VolumeReader
+	clock clock.PassiveClock
+
+	// clusterDefaults are the cluster-wide default attributes, layered under
+	// the attributes of each volume.
+	clusterDefaults map[string]string
+
+	issuanceAttempts  *prometheus.CounterVec
+	issuanceSuccesses *prometheus.CounterVec
+	issuanceFailures  *prometheus.CounterVec
+	issuanceDuration  *prometheus.HistogramVec
+
+	readinessGateTimeouts *prometheus.CounterVec
+
+	certificateNotAfter *prometheus.Desc
+	nextIssuanceTime    *prometheus.Desc
+
+	// inflightIssuanceTime holds the start time of the current issuance
+	// attempt for each volume, keyed by volume ID.
+	inflightLock         sync.Mutex
+	inflightIssuanceTime map[string]time.Time
+}
+
+// New constructs a new set of driver metrics, reading per-volume state from
+// the given store, and defaulting volume attributes with the given
+// cluster-wide defaults. The returned Metrics must be registered with a
+// prometheus.Registerer before they are served.
+func New(store VolumeReader, clock clock.PassiveClock, clusterDefaults map[string]string) *Metrics {
+	return &Metrics{
+		store:           store,
+		clock:           clock,
+		clusterDefaults: clusterDefaults,
+
+		issuanceAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "certificate_issuance_attempts_total",
+			Help:      "The number of certificate issuance attempts started by the driver.",
+		}, issuanceLabels),
+		issuanceSuccesses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "certificate_issuance_successes_total",
+			Help:      "The number of certificate issuance attempts which resulted in a signed certificate being written to the volume.",
+		}, issuanceLabels),
+		issuanceFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "certificate_issuance_failures_total",
+			Help:      "The number of certificate issuance attempts which failed, or were retried before a certificate was written to the volume.",
+		}, issuanceLabels),
+		issuanceDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "certificate_issuance_duration_seconds",
+			Help:      "The time taken from the start of a successful issuance attempt until the certificate was written to the volume.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}, issuanceLabels),
+
+		readinessGateTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "readiness_gate_timeouts_total",
+			Help:      "The number of volumes which were not ready to request their first certificate within the readiness gate timeout, by the timeout policy applied.",
+		}, readinessGateTimeoutLabels),
+
+		certificateNotAfter: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "certificate_expiration_timestamp_seconds"),
+			"The NotAfter time of the certificate currently written to the volume, in seconds since the Unix epoch.",
+			volumeLabels, nil,
+		),
+		nextIssuanceTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "certificate_next_issuance_timestamp_seconds"),
+			"The time at which the driver will next attempt to issue a certificate for the volume, in seconds since the Unix epoch.",
+			volumeLabels, nil,
+		),
+
+		inflightIssuanceTime: make(map[string]time.Time),
+	}
+}
+
+// Register registers all driver metrics with the given registerer.
+func (m *Metrics) Register(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{
+		m.issuanceAttempts,
+		m.issuanceSuccesses,
+		m.issuanceFailures,
+		m.issuanceDuration,
+		m.readinessGateTimeouts,
+		m,
+	} {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Instrument wraps the GeneratePrivateKey, GenerateRequest, SignRequest and
+// WriteKeypair functions of the given manager options so that issuance
+// attempts, successes, failures and latency are recorded. It must be called
+// after those functions have been set.
+//
+// An attempt begins when the manager generates a private key for a volume,
+// and succeeds when the resulting keypair is written. An attempt fails if any
+// of the wrapped functions return an error, or if a new attempt is started for
+// the same volume before the previous one wrote a keypair (e.g. because the
+// CertificateRequest was denied or timed out inside csi-lib).
+func (m *Metrics) Instrument(opts *manager.Options) {
+	generatePrivateKey := opts.GeneratePrivateKey
+	generateRequest := opts.GenerateRequest
+	signRequest := opts.SignRequest
+	writeKeypair := opts.WriteKeypair
+
+	opts.GeneratePrivateKey = func(meta metadata.Metadata) (crypto.PrivateKey, error) {
+		m.startAttempt(meta)
+		key, err := generatePrivateKey(meta)
+		if err != nil {
+			m.failAttempt(meta)
+		}
+		return key, err
+	}
+
+	opts.GenerateRequest = func(meta metadata.Metadata) (*manager.CertificateRequestBundle, error) {
+		bundle, err := generateRequest(meta)
+		if err != nil {
+			m.failAttempt(meta)
+		}
+		return bundle, err
+	}
+
+	opts.SignRequest = func(meta metadata.Metadata, key crypto.PrivateKey, request *x509.CertificateRequest) ([]byte, error) {
+		csr, err := signRequest(meta, key, request)
+		if err != nil {
+			m.failAttempt(meta)
+		}
+		return csr, err
+	}
+
+	opts.WriteKeypair = func(meta metadata.Metadata, key crypto.PrivateKey, chain []byte, ca []byte) error {
+		if err := writeKeypair(meta, key, chain, ca); err != nil {
+			m.failAttempt(meta)
+			return err
+		}
+		m.succeedAttempt(meta)
+		return nil
+	}
+}
+
+// ReadinessGatesTimedOut records that the volume was not ready within the
+// readiness gate timeout, and the policy applied to it.
+func (m *Metrics) ReadinessGatesTimedOut(meta metadata.Metadata, timeoutPolicy readinessgate.TimeoutPolicy) {
+	labels := append(m.issuanceLabelValues(meta), string(timeoutPolicy))
+	m.readinessGateTimeouts.WithLabelValues(labels...).Inc()
+}
+
+// startAttempt records the start of an issuance attempt for the volume. If a
+// previous attempt for the volume is still in flight, it is counted as failed.
+func (m *Metrics) startAttempt(meta metadata.Metadata) {
+	labels := m.issuanceLabelValues(meta)
+
+	m.inflightLock.Lock()
+	defer m.inflightLock.Unlock()
+
+	if _, ok := m.inflightIssuanceTime[meta.VolumeID]; ok {
+		m.issuanceFailures.WithLabelValues(labels...).Inc()
+	}
+	m.inflightIssuanceTime[meta.VolumeID] = m.clock.Now()
+	m.issuanceAttempts.WithLabelValues(labels...).Inc()
+}
+
+// failAttempt records a failed issuance attempt for the volume.
+func (m *Metrics) failAttempt(meta metadata.Metadata) {
+	m.inflightLock.Lock()
+	defer m.inflightLock.Unlock()
+
+	// Only count the failure once, in case the attempt was never started or
+	// has already been counted as failed.
+	if _, ok := m.inflightIssuanceTime[meta.VolumeID]; !ok {
+		return
+	}
+	delete(m.inflightIssuanceTime, meta.VolumeID)
+	m.issuanceFailures.WithLabelValues(m.issuanceLabelValues(meta)...).Inc()
+}
+
+// succeedAttempt records a successful issuance attempt for the volume, along
+// with the time taken since the attempt started.
+func (m *Metrics) succeedAttempt(meta metadata.Metadata) {
+	labels := m.issuanceLabelValues(meta)
+
+	m.inflightLock.Lock()
+	defer m.inflightLock.Unlock()
+
+	if start, ok := m.inflightIssuanceTime[meta.VolumeID]; ok {
+		m.issuanceDuration.WithLabelValues(labels...).Observe(m.clock.Since(start).Seconds())
+		delete(m.inflightIssuanceTime, meta.VolumeID)
+	}
+	m.issuanceSuccesses.WithLabelValues(labels...).Inc()
+}
+
+// Describe implements prometheus.Collector for the per-volume gauges.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.certificateNotAfter
+	ch <- m.nextIssuanceTime
+}
+
+// Collect implements prometheus.Collector for the per-volume gauges. Volumes
+// are read from the store on each scrape. Volumes whose metadata or
+// certificate cannot be read are skipped, since they are either being
+// published or unpublished, or have not yet been issued a certificate.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	volumeIDs, err := m.store.ListVolumes()
+	if err != nil {
+		return
+	}
+
+	present := make(map[string]struct{}, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		present[volumeID] = struct{}{}
+
+		meta, err := m.store.ReadMetadata(volumeID)
+		if err != nil {
+			continue
+		}
+
+		labels := m.volumeLabelValues(meta)
+
+		if meta.NextIssuanceTime != nil {
+			ch <- prometheus.MustNewConstMetric(m.nextIssuanceTime, prometheus.GaugeValue,
+				float64(meta.NextIssuanceTime.Unix()), labels...)
+		}
+
+		if notAfter, ok := m.certificateNotAfterForVolume(meta); ok {
+			ch <- prometheus.MustNewConstMetric(m.certificateNotAfter, prometheus.GaugeValue,
+				float64(notAfter.Unix()), labels...)
+		}
+	}
+
+	// Forget in-flight attempts for volumes which have since been removed, so
+	// the map doesn't grow unbounded over the lifetime of the driver.
+	m.inflightLock.Lock()
+	defer m.inflightLock.Unlock()
+	for volumeID := range m.inflightIssuanceTime {
+		if _, ok := present[volumeID]; !ok {
+			delete(m.inflightIssuanceTime, volumeID)
+		}
+	}
+}
+
+// certificateNotAfterForVolume returns the NotAfter time of the leaf
+// certificate written to the volume, if one exists.
+func (m *Metrics) certificateNotAfterForVolume(meta metadata.Metadata) (time.Time, bool) {
+	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext, m.clusterDefaults)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	certPEM, err := m.store.ReadFile(meta.VolumeID, attrs[csiapi.CertFileKey])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return time.Time{}, false
+	}
+
+	crt, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return crt.NotAfter, true
+}
+
+// issuanceLabelValues returns the issuance label values for the volume, in the
+// order of issuanceLabels.
+func (m *Metrics) issuanceLabelValues(meta metadata.Metadata) []string {
+	attrs := m.defaultedAttributes(meta)
+	return []string{
+		attrs[csiapi.K8sVolumeContextKeyPodNamespace],
+		attrs[csiapi.IssuerNameKey],
+		attrs[csiapi.IssuerKindKey],
+		attrs[csiapi.IssuerGroupKey],
+	}
+}
+
+// volumeLabelValues returns the per-volume label values for the volume, in
+// the order of volumeLabels.
+func (m *Metrics) volumeLabelValues(meta metadata.Metadata) []string {
+	attrs := m.defaultedAttributes(meta)
+	return []string{
+		meta.VolumeID,
+		attrs[csiapi.K8sVolumeContextKeyPodNamespace],
+		attrs[csiapi.K8sVolumeContextKeyPodName],
+		attrs[csiapi.IssuerNameKey],
+		attrs[csiapi.IssuerKindKey],
+		attrs[csiapi.IssuerGroupKey],
+	}
+}
+
+// defaultedAttributes returns the volume attributes with defaults applied, so
+// that the issuer kind and group labels match what is actually requested.
+// Falls back to the raw volume context if defaulting fails.
+func (m *Metrics) defaultedAttributes(meta metadata.Metadata) map[string]string {
+	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext, m.clusterDefaults)
+	if err != nil {
+		return meta.VolumeContext
+	}
+	return attrs
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cert-manager/csi-lib/manager"
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/cert-manager/csi-lib/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fakeclock "k8s.io/utils/clock/testing"
+
+	"github.com/cert-manager/csi-driver/pkg/readinessgate"
+	"github.com/cert-manager/csi-driver/test/unit"
+)
+
+// memoryStore adds ReadFile to storage.MemoryFS so it satisfies VolumeReader.
+type memoryStore struct {
+	*storage.MemoryFS
+}
+
+func (m memoryStore) ReadFile(volumeID, name string) ([]byte, error) {
+	files, err := m.ReadFiles(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := files[name]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return data, nil
+}
+
+func testMetadata(volumeID string) metadata.Metadata {
+	return metadata.Metadata{
+		VolumeID: volumeID,
+		VolumeContext: map[string]string{
+			"csi.cert-manager.io/issuer-name":  "my-issuer",
+			"csi.storage.k8s.io/pod.name":      "my-pod",
+			"csi.storage.k8s.io/pod.namespace": "my-namespace",
+		},
+	}
+}
+
+func Test_Instrument(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := map[string]struct {
+		// calls is the sequence of manager steps to run, each either
+		// succeeding or failing.
+		calls []string
+
+		expAttempts  float64
+		expSuccesses float64
+		expFailures  float64
+		expDuration  uint64
+	}{
+		"a successful issuance should record an attempt, success and duration": {
+			calls:        []string{"key", "request", "sign", "write"},
+			expAttempts:  1,
+			expSuccesses: 1,
+			expDuration:  1,
+		},
+		"a failed key generation should record a failure": {
+			calls:       []string{"key-fail"},
+			expAttempts: 1,
+			expFailures: 1,
+		},
+		"a failed request generation should record a failure": {
+			calls:       []string{"key", "request-fail"},
+			expAttempts: 1,
+			expFailures: 1,
+		},
+		"a failed write should record a failure": {
+			calls:       []string{"key", "request", "sign", "write-fail"},
+			expAttempts: 1,
+			expFailures: 1,
+		},
+		"an attempt superseded by a retry should record a failure": {
+			calls:        []string{"key", "request", "sign", "key", "request", "sign", "write"},
+			expAttempts:  2,
+			expSuccesses: 1,
+			expFailures:  1,
+			expDuration:  1,
+		},
+		"a failed attempt followed by a retry should only record one failure": {
+			calls:        []string{"key", "request-fail", "key", "request", "sign", "write"},
+			expAttempts:  2,
+			expSuccesses: 1,
+			expFailures:  1,
+			expDuration:  1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := New(memoryStore{storage.NewMemoryFS()}, fakeclock.NewFakeClock(time.Now()), nil)
+
+			fail := map[string]bool{}
+			result := func(step string) error {
+				if fail[step] {
+					return errFailed
+				}
+				return nil
+			}
+
+			opts := manager.Options{
+				GeneratePrivateKey: func(metadata.Metadata) (crypto.PrivateKey, error) { return nil, result("key") },
+				GenerateRequest: func(metadata.Metadata) (*manager.CertificateRequestBundle, error) {
+					return nil, result("request")
+				},
+				SignRequest: func(metadata.Metadata, crypto.PrivateKey, *x509.CertificateRequest) ([]byte, error) {
+					return nil, result("sign")
+				},
+				WriteKeypair: func(metadata.Metadata, crypto.PrivateKey, []byte, []byte) error { return result("write") },
+			}
+			m.Instrument(&opts)
+
+			meta := testMetadata("vol-id")
+			for _, call := range test.calls {
+				step, failed := strings.CutSuffix(call, "-fail")
+				fail[step] = failed
+
+				switch step {
+				case "key":
+					_, _ = opts.GeneratePrivateKey(meta)
+				case "request":
+					_, _ = opts.GenerateRequest(meta)
+				case "sign":
+					_, _ = opts.SignRequest(meta, nil, nil)
+				case "write":
+					_ = opts.WriteKeypair(meta, nil, nil, nil)
+				}
+			}
+
+			labels := []string{"my-namespace", "my-issuer", "Issuer", "cert-manager.io"}
+			assert.Equal(t, test.expAttempts, testutil.ToFloat64(m.issuanceAttempts.WithLabelValues(labels...)))
+			assert.Equal(t, test.expSuccesses, testutil.ToFloat64(m.issuanceSuccesses.WithLabelValues(labels...)))
+			assert.Equal(t, test.expFailures, testutil.ToFloat64(m.issuanceFailures.WithLabelValues(labels...)))
+			assert.Equal(t, test.expDuration, histogramSampleCount(t, m.issuanceDuration, labels))
+		})
+	}
+}
+
+func Test_Collect(t *testing.T) {
+	store := memoryStore{storage.NewMemoryFS()}
+	m := New(store, fakeclock.NewFakeClock(time.Now()), nil)
+
+	bundle := unit.MustCreateBundle(t, nil, "leaf")
+	nextIssuanceTime := time.Unix(1000, 0)
+
+	issued := testMetadata("issued")
+	issued.NextIssuanceTime = &nextIssuanceTime
+	_, err := store.RegisterMetadata(issued)
+	require.NoError(t, err)
+	require.NoError(t, store.WriteFiles(issued, map[string][]byte{"tls.crt": bundle.PEM}))
+
+	// A volume which has not yet been issued a certificate should not expose
+	// any gauges.
+	_, err = store.RegisterMetadata(testMetadata("pending"))
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, m.Register(reg))
+
+	labels := `issuer_group="cert-manager.io",issuer_kind="Issuer",issuer_name="my-issuer",namespace="my-namespace",pod="my-pod",volume_id="issued"`
+	expected := `
+# HELP certmanager_csi_driver_certificate_expiration_timestamp_seconds The NotAfter time of the certificate currently written to the volume, in seconds since the Unix epoch.
+# TYPE certmanager_csi_driver_certificate_expiration_timestamp_seconds gauge
+certmanager_csi_driver_certificate_expiration_timestamp_seconds{` + labels + `} ` + strconv.FormatInt(bundle.Cert.NotAfter.Unix(), 10) + `
+# HELP certmanager_csi_driver_certificate_next_issuance_timestamp_seconds The time at which the driver will next attempt to issue a certificate for the volume, in seconds since the Unix epoch.
+# TYPE certmanager_csi_driver_certificate_next_issuance_timestamp_seconds gauge
+certmanager_csi_driver_certificate_next_issuance_timestamp_seconds{` + labels + `} 1000
+`
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"certmanager_csi_driver_certificate_expiration_timestamp_seconds",
+		"certmanager_csi_driver_certificate_next_issuance_timestamp_seconds",
+	))
+
+	// Removing the volume should remove its gauges.
+	require.NoError(t, store.RemoveVolume("issued"))
+	count, err := testutil.GatherAndCount(reg,
+		"certmanager_csi_driver_certificate_expiration_timestamp_seconds",
+		"certmanager_csi_driver_certificate_next_issuance_timestamp_seconds",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func Test_ReadinessGatesTimedOut(t *testing.T) {
+	m := New(memoryStore{storage.NewMemoryFS()}, fakeclock.NewFakeClock(time.Now()), nil)
+
+	m.ReadinessGatesTimedOut(testMetadata("vol-1"), readinessgate.TimeoutPolicyFail)
+	m.ReadinessGatesTimedOut(testMetadata("vol-2"), readinessgate.TimeoutPolicyFail)
+	m.ReadinessGatesTimedOut(testMetadata("vol-3"), readinessgate.TimeoutPolicyIssue)
+
+	labels := []string{"my-namespace", "my-issuer", "Issuer", "cert-manager.io"}
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.readinessGateTimeouts.WithLabelValues(append(labels, "fail")...)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.readinessGateTimeouts.WithLabelValues(append(labels, "issue")...)))
+}
+
+func histogramSampleCount(t *testing.T, h *prometheus.HistogramVec, labels []string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, h.WithLabelValues(labels...).(prometheus.Metric).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
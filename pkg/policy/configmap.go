@@ -0,0 +1,89 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ConfigMapKey is the key in the ConfigMap's data holding the policy.
+const ConfigMapKey = "policy.yaml"
+
+// WatchConfigMap loads the policy from the named ConfigMap, and reloads it
+// whenever the ConfigMap changes until the context is done. It returns once
+// the ConfigMap has been synced. If the ConfigMap doesn't exist, is deleted,
+// or contains an invalid policy, the current policy is kept, so requests are
+// never created unrestricted.
+func (e *Engine) WatchConfigMap(ctx context.Context, log logr.Logger, client kubernetes.Interface, namespace, name string) error {
+	log = log.WithValues("configmap", namespace+"/"+name)
+
+	// Scope the informer to the single ConfigMap, so that RBAC can restrict
+	// the driver to it by resourceNames, rather than granting access to every
+	// ConfigMap in the namespace.
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		client,
+		0, // no periodic resync; informer events are sufficient
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+	)
+	informer := factory.Core().V1().ConfigMaps().Informer()
+
+	load := func(obj any) {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return
+		}
+		data, ok := cm.Data[ConfigMapKey]
+		if !ok {
+			log.Error(nil, "issuance policy ConfigMap is missing key, keeping the current policy", "key", ConfigMapKey)
+			return
+		}
+		if err := e.Load([]byte(data)); err != nil {
+			log.Error(err, "failed to load issuance policy, keeping the current policy")
+			return
+		}
+		log.Info("loaded issuance policy")
+	}
+
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    load,
+		UpdateFunc: func(_, obj any) { load(obj) },
+		DeleteFunc: func(any) {
+			log.Error(nil, "issuance policy ConfigMap was deleted, keeping the current policy")
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to add issuance policy ConfigMap event handler: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("failed to sync issuance policy ConfigMap informer cache")
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cert-manager/csi-lib/manager"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_WatchConfigMap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cert-manager"},
+		Data:       map[string]string{ConfigMapKey: `policies: [{namespaces: ["*"]}]`},
+	}
+	client := fake.NewClientset(cm)
+
+	engine := NewEngine()
+	require.NoError(t, engine.WatchConfigMap(ctx, logr.Discard(), client, "cert-manager", "policy"))
+
+	caBundle := testBundle(func(b *manager.CertificateRequestBundle) { b.IsCA = true })
+	assert.ErrorAs(t, engine.Check(caBundle), new(*DeniedError), "the initial policy should be loaded")
+
+	// An update should reload the policy.
+	cm.Data[ConfigMapKey] = `policies: [{namespaces: ["*"], allowCA: true}]`
+	_, err := client.CoreV1().ConfigMaps("cert-manager").Update(ctx, cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return engine.Check(caBundle) == nil }, 5*time.Second, 10*time.Millisecond)
+
+	// An invalid update should keep the current policy.
+	cm.Data[ConfigMapKey] = `policies: [{namespaces: []}]`
+	_, err = client.CoreV1().ConfigMaps("cert-manager").Update(ctx, cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Never(t, func() bool { return engine.Check(caBundle) != nil }, 200*time.Millisecond, 10*time.Millisecond)
+
+	// Deleting the ConfigMap should keep the current policy.
+	require.NoError(t, client.CoreV1().ConfigMaps("cert-manager").Delete(ctx, "policy", metav1.DeleteOptions{}))
+	assert.Never(t, func() bool { return engine.Check(caBundle) != nil }, 200*time.Millisecond, 10*time.Millisecond)
+}
+
+func Test_WatchConfigMapMissing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	engine := NewEngine()
+	require.NoError(t, engine.WatchConfigMap(ctx, logr.Discard(), fake.NewClientset(), "cert-manager", "policy"))
+
+	assert.ErrorIs(t, engine.Check(testBundle(nil)), ErrNotLoaded,
+		"requests should be rejected until the ConfigMap exists")
+}
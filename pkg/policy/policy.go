@@ -0,0 +1,355 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy implements the driver's namespace-scoped issuance policy,
+// which restricts the CertificateRequests that pods in each namespace may
+// cause the driver to create, without relying on a separate approver.
+package policy
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/csi-lib/manager"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the issuance policy configuration, read from a file or from a
+// ConfigMap.
+type Config struct {
+	// Policies are matched in order against the namespace of each request.
+	// Only the first matching policy applies. Requests in namespaces which no
+	// policy matches are not restricted; add a final policy for the "*"
+	// namespace to restrict every other namespace.
+	Policies []Policy `json:"policies"`
+}
+
+// Policy restricts the requests made for volumes of pods in the matching
+// namespaces.
+type Policy struct {
+	// Namespaces are the namespaces this policy applies to. Each entry may be
+	// a glob pattern, e.g. "team-a-*" or "*".
+	Namespaces []string `json:"namespaces"`
+
+	// AllowedIssuers are the issuers which may be referenced. Empty fields of
+	// an entry match any value. If empty, any issuer may be referenced.
+	AllowedIssuers []IssuerReference `json:"allowedIssuers,omitempty"`
+
+	// MaxDuration is the maximum duration which may be requested. If unset,
+	// any duration may be requested.
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
+
+	// AllowCA is whether CA certificates may be requested, i.e. with
+	// csi.cert-manager.io/is-ca set to "true".
+	AllowCA bool `json:"allowCA,omitempty"`
+
+	// AllowedDNSNames are regular expressions, one of which every requested
+	// DNS name must fully match. If empty, any DNS name may be requested.
+	AllowedDNSNames []string `json:"allowedDNSNames,omitempty"`
+
+	// AllowedURISANs are regular expressions, one of which every requested
+	// URI SAN must fully match. If empty, any URI SAN may be requested.
+	AllowedURISANs []string `json:"allowedURISANs,omitempty"`
+
+	// AllowedCommonNames are regular expressions, one of which the requested
+	// common name must fully match. If empty, the common name must instead
+	// match one of AllowedDNSNames, if any are set.
+	AllowedCommonNames []string `json:"allowedCommonNames,omitempty"`
+}
+
+// IssuerReference matches a referenced issuer. Empty fields match any value.
+type IssuerReference struct {
+	Name  string `json:"name,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// DeniedError is returned when a request violates the policy for its
+// namespace.
+type DeniedError struct {
+	// Namespace is the namespace of the denied request.
+	Namespace string
+
+	// Violations describe each way in which the request violates the policy.
+	Violations []string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("request denied by issuance policy for namespace %q: %s",
+		e.Namespace, strings.Join(e.Violations, "; "))
+}
+
+// ErrNotLoaded is returned when a request is checked before any policy has
+// been loaded, so that requests are not created unrestricted while a
+// ConfigMap is being synced or is missing.
+var ErrNotLoaded = errors.New("issuance policy has not been loaded")
+
+// oidCommonName is the object identifier of the common name attribute.
+var oidCommonName = asn1.ObjectIdentifier{2, 5, 4, 3}
+
+// compiledPolicy is a Policy with its regular expressions compiled.
+type compiledPolicy struct {
+	Policy
+
+	dnsNames    []*regexp.Regexp
+	uriSANs     []*regexp.Regexp
+	commonNames []*regexp.Regexp
+}
+
+// Engine checks requests against the loaded issuance policy. The policy may
+// be reloaded at any time, e.g. when a watched ConfigMap changes.
+type Engine struct {
+	lock     sync.RWMutex
+	loaded   bool
+	policies []compiledPolicy
+}
+
+// NewEngine returns an Engine with no policy loaded. All requests are
+// rejected with ErrNotLoaded until Load succeeds.
+func NewEngine() *Engine {
+	return new(Engine)
+}
+
+// LoadFile loads the policy from the given YAML file.
+func (e *Engine) LoadFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read issuance policy: %w", err)
+	}
+	return e.Load(data)
+}
+
+// Load parses and validates the given YAML policy configuration, and
+// replaces the current policy with it. The current policy is kept if the
+// configuration is invalid.
+func (e *Engine) Load(data []byte) error {
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return fmt.Errorf("failed to parse issuance policy: %w", err)
+	}
+
+	policies, err := compile(config)
+	if err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.loaded = true
+	e.policies = policies
+
+	return nil
+}
+
+// Check returns a DeniedError if the request violates the policy for its
+// namespace.
+func (e *Engine) Check(bundle *manager.CertificateRequestBundle) error {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if !e.loaded {
+		return ErrNotLoaded
+	}
+
+	for _, policy := range e.policies {
+		if !policy.matchesNamespace(bundle.Namespace) {
+			continue
+		}
+
+		if violations := policy.check(bundle); len(violations) > 0 {
+			return &DeniedError{Namespace: bundle.Namespace, Violations: violations}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// compile validates the configuration, and compiles its regular expressions.
+func compile(config Config) ([]compiledPolicy, error) {
+	var el field.ErrorList
+	var policies []compiledPolicy
+
+	for i, policy := range config.Policies {
+		fldPath := field.NewPath("policies").Index(i)
+
+		if len(policy.Namespaces) == 0 {
+			el = append(el, field.Required(fldPath.Child("namespaces"), "at least one namespace is required"))
+		}
+		for j, ns := range policy.Namespaces {
+			if _, err := path.Match(ns, ""); err != nil {
+				el = append(el, field.Invalid(fldPath.Child("namespaces").Index(j), ns, err.Error()))
+			}
+		}
+
+		if policy.MaxDuration != nil && policy.MaxDuration.Duration <= 0 {
+			el = append(el, field.Invalid(fldPath.Child("maxDuration"), policy.MaxDuration.Duration.String(), "must be greater than 0"))
+		}
+
+		compiled := compiledPolicy{Policy: policy}
+		compiled.dnsNames, el = compileRegexps(fldPath.Child("allowedDNSNames"), policy.AllowedDNSNames, el)
+		compiled.uriSANs, el = compileRegexps(fldPath.Child("allowedURISANs"), policy.AllowedURISANs, el)
+		compiled.commonNames, el = compileRegexps(fldPath.Child("allowedCommonNames"), policy.AllowedCommonNames, el)
+		if len(compiled.commonNames) == 0 {
+			compiled.commonNames = compiled.dnsNames
+		}
+		policies = append(policies, compiled)
+	}
+
+	if len(el) > 0 {
+		return nil, fmt.Errorf("invalid issuance policy: %w", el.ToAggregate())
+	}
+
+	return policies, nil
+}
+
+// compileRegexps compiles the given regular expressions so that they must
+// match the whole of a value, appending any errors to el.
+func compileRegexps(fldPath *field.Path, exprs []string, el field.ErrorList) ([]*regexp.Regexp, field.ErrorList) {
+	var regexps []*regexp.Regexp
+	for i, expr := range exprs {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			el = append(el, field.Invalid(fldPath.Index(i), expr, err.Error()))
+			continue
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, el
+}
+
+func (p *compiledPolicy) matchesNamespace(namespace string) bool {
+	for _, pattern := range p.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns a description of each way the request violates the policy.
+func (p *compiledPolicy) check(bundle *manager.CertificateRequestBundle) []string {
+	var violations []string
+
+	if len(p.AllowedIssuers) > 0 && !p.allowsIssuer(bundle) {
+		violations = append(violations, fmt.Sprintf("issuer %s.%s/%s is not allowed",
+			bundle.IssuerRef.Kind, bundle.IssuerRef.Group, bundle.IssuerRef.Name))
+	}
+
+	if p.MaxDuration != nil && bundle.Duration > p.MaxDuration.Duration {
+		violations = append(violations, fmt.Sprintf("duration %s exceeds the maximum of %s",
+			bundle.Duration, p.MaxDuration.Duration.Round(time.Second)))
+	}
+
+	if bundle.IsCA && !p.AllowCA {
+		violations = append(violations, "CA certificates are not allowed")
+	}
+
+	if len(p.dnsNames) > 0 {
+		for _, dnsName := range bundle.Request.DNSNames {
+			if !matchesAny(p.dnsNames, dnsName) {
+				violations = append(violations, fmt.Sprintf("DNS name %q is not allowed", dnsName))
+			}
+		}
+	}
+
+	if len(p.commonNames) > 0 {
+		commonNames, err := requestCommonNames(bundle.Request)
+		if err != nil {
+			violations = append(violations, err.Error())
+		}
+		for _, commonName := range commonNames {
+			if !matchesAny(p.commonNames, commonName) {
+				violations = append(violations, fmt.Sprintf("common name %q is not allowed", commonName))
+			}
+		}
+	}
+
+	if len(p.uriSANs) > 0 {
+		for _, uri := range bundle.Request.URIs {
+			if !matchesAny(p.uriSANs, uri.String()) {
+				violations = append(violations, fmt.Sprintf("URI SAN %q is not allowed", uri.String()))
+			}
+		}
+	}
+
+	return violations
+}
+
+func (p *compiledPolicy) allowsIssuer(bundle *manager.CertificateRequestBundle) bool {
+	for _, issuer := range p.AllowedIssuers {
+		if (issuer.Name == "" || issuer.Name == bundle.IssuerRef.Name) &&
+			(issuer.Kind == "" || issuer.Kind == bundle.IssuerRef.Kind) &&
+			(issuer.Group == "" || issuer.Group == bundle.IssuerRef.Group) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestCommonNames returns the common names of the request's subject. A
+// literal subject is only set as the raw subject, and may hold more than one
+// common name.
+func requestCommonNames(request *x509.CertificateRequest) ([]string, error) {
+	if len(request.RawSubject) == 0 {
+		if len(request.Subject.CommonName) == 0 {
+			return nil, nil
+		}
+		return []string{request.Subject.CommonName}, nil
+	}
+
+	var rdnSequence pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(request.RawSubject, &rdnSequence); err != nil {
+		return nil, fmt.Errorf("failed to parse subject: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("failed to parse subject: trailing data")
+	}
+
+	var commonNames []string
+	for _, rdn := range rdnSequence {
+		for _, atv := range rdn {
+			if !atv.Type.Equal(oidCommonName) {
+				continue
+			}
+			commonName, ok := atv.Value.(string)
+			if !ok {
+				return nil, errors.New("failed to parse subject: common name is not a string")
+			}
+			commonNames = append(commonNames, commonName)
+		}
+	}
+	return commonNames, nil
+}
+
+func matchesAny(regexps []*regexp.Regexp, s string) bool {
+	for _, re := range regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
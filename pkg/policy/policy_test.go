@@ -0,0 +1,241 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+	"time"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmpki "github.com/cert-manager/cert-manager/pkg/util/pki"
+	"github.com/cert-manager/csi-lib/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+policies:
+- namespaces: ["team-a", "team-a-*"]
+  allowedIssuers:
+  - name: team-a-issuer
+    kind: Issuer
+  - kind: ClusterIssuer
+    name: shared
+    group: cert-manager.io
+  maxDuration: 720h
+  allowedDNSNames:
+  - '[a-z0-9-]+\.team-a\.svc\.cluster\.local'
+  allowedURISANs:
+  - 'spiffe://cluster\.local/ns/team-a/.*'
+- namespaces: ["platform"]
+  allowCA: true
+  allowedDNSNames:
+  - '[a-z0-9-]+\.platform\.svc'
+  allowedCommonNames:
+  - 'platform-[a-z0-9-]+'
+- namespaces: ["*"]
+  maxDuration: 24h
+`
+
+func testBundle(mod func(*manager.CertificateRequestBundle)) *manager.CertificateRequestBundle {
+	bundle := &manager.CertificateRequestBundle{
+		Request:   &x509.CertificateRequest{},
+		Namespace: "team-a",
+		Duration:  time.Hour,
+		IssuerRef: cmmeta.IssuerReference{
+			Name:  "team-a-issuer",
+			Kind:  "Issuer",
+			Group: "cert-manager.io",
+		},
+	}
+	if mod != nil {
+		mod(bundle)
+	}
+	return bundle
+}
+
+func rawSubject(t *testing.T, subject string) []byte {
+	rdnSequence, err := cmpki.UnmarshalSubjectStringToRDNSequence(subject)
+	require.NoError(t, err)
+	raw, err := cmpki.MarshalRDNSequenceToRawDERBytes(rdnSequence)
+	require.NoError(t, err)
+	return raw
+}
+
+func Test_Check(t *testing.T) {
+	tests := map[string]struct {
+		bundle *manager.CertificateRequestBundle
+		expErr string
+	}{
+		"a request which satisfies the policy should be allowed": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Request.DNSNames = []string{"my-app.team-a.svc.cluster.local"}
+				b.Request.URIs = []*url.URL{{Scheme: "spiffe", Host: "cluster.local", Path: "/ns/team-a/sa/my-app"}}
+			}),
+		},
+		"a request in a namespace matching a glob should use that policy": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Namespace = "team-a-dev"
+				b.Duration = 48 * time.Hour
+			}),
+		},
+		"an issuer matching an entry with all fields should be allowed": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.IssuerRef = cmmeta.IssuerReference{Name: "shared", Kind: "ClusterIssuer", Group: "cert-manager.io"}
+			}),
+		},
+		"an issuer not in the allowlist should be denied": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.IssuerRef = cmmeta.IssuerReference{Name: "other", Kind: "ClusterIssuer", Group: "cert-manager.io"}
+			}),
+			expErr: `request denied by issuance policy for namespace "team-a": issuer ClusterIssuer.cert-manager.io/other is not allowed`,
+		},
+		"every violation should be reported": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Duration = 1000 * time.Hour
+				b.IsCA = true
+				b.Request.DNSNames = []string{"my-app.team-a.svc.cluster.local", "my-app.team-b.svc.cluster.local"}
+				b.Request.URIs = []*url.URL{{Scheme: "spiffe", Host: "cluster.local", Path: "/ns/team-b/sa/my-app"}}
+			}),
+			expErr: `request denied by issuance policy for namespace "team-a": ` +
+				`duration 1000h0m0s exceeds the maximum of 720h0m0s; ` +
+				`CA certificates are not allowed; ` +
+				`DNS name "my-app.team-b.svc.cluster.local" is not allowed; ` +
+				`URI SAN "spiffe://cluster.local/ns/team-b/sa/my-app" is not allowed`,
+		},
+		"DNS name regexes should match the whole name": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Request.DNSNames = []string{"my-app.team-a.svc.cluster.local.evil.com"}
+			}),
+			expErr: `request denied by issuance policy for namespace "team-a": DNS name "my-app.team-a.svc.cluster.local.evil.com" is not allowed`,
+		},
+		"a common name matching an allowed DNS name should be allowed": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Request.Subject.CommonName = "my-app.team-a.svc.cluster.local"
+			}),
+		},
+		"a common name not matching an allowed DNS name should be denied": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Request.Subject.CommonName = "my-app.team-b.svc.cluster.local"
+			}),
+			expErr: `request denied by issuance policy for namespace "team-a": common name "my-app.team-b.svc.cluster.local" is not allowed`,
+		},
+		"a common name in a literal subject should be checked": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Request.RawSubject = rawSubject(t, "CN=my-app.team-b.svc.cluster.local,O=team-a")
+			}),
+			expErr: `request denied by issuance policy for namespace "team-a": common name "my-app.team-b.svc.cluster.local" is not allowed`,
+		},
+		"allowed common names should be used instead of allowed DNS names": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Namespace = "platform"
+				b.Request.Subject.CommonName = "platform-ingress"
+			}),
+		},
+		"a common name not matching the allowed common names should be denied": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Namespace = "platform"
+				b.Request.Subject.CommonName = "ingress.platform.svc"
+			}),
+			expErr: `request denied by issuance policy for namespace "platform": common name "ingress.platform.svc" is not allowed`,
+		},
+		"only the first matching policy should apply": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Namespace = "platform"
+				b.IsCA = true
+				b.Duration = 1000 * time.Hour
+			}),
+		},
+		"the catch-all policy should apply to other namespaces": {
+			bundle: testBundle(func(b *manager.CertificateRequestBundle) {
+				b.Namespace = "team-b"
+				b.Duration = 48 * time.Hour
+			}),
+			expErr: `request denied by issuance policy for namespace "team-b": duration 48h0m0s exceeds the maximum of 24h0m0s`,
+		},
+	}
+
+	engine := NewEngine()
+	require.NoError(t, engine.Load([]byte(testPolicy)))
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := engine.Check(test.bundle)
+			if test.expErr == "" {
+				assert.NoError(t, err)
+			} else {
+				var denied *DeniedError
+				assert.ErrorAs(t, err, &denied)
+				assert.EqualError(t, err, test.expErr)
+			}
+		})
+	}
+}
+
+func Test_CheckWithoutMatchingPolicy(t *testing.T) {
+	engine := NewEngine()
+	assert.ErrorIs(t, engine.Check(testBundle(nil)), ErrNotLoaded)
+
+	require.NoError(t, engine.Load([]byte(`policies: [{namespaces: [other]}]`)))
+	assert.NoError(t, engine.Check(testBundle(func(b *manager.CertificateRequestBundle) {
+		b.IsCA = true
+	})))
+}
+
+func Test_Load(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		expErr string
+	}{
+		"an empty policy should load": {
+			config: "",
+		},
+		"unknown fields should error": {
+			config: "policies: [{namespaces: [a], allowIssuers: []}]",
+			expErr: `failed to parse issuance policy: error unmarshaling JSON: while decoding JSON: json: unknown field "allowIssuers"`,
+		},
+		"invalid policies should report every error": {
+			config: `
+policies:
+- maxDuration: 0s
+- namespaces: ["[a-"]
+  allowedDNSNames: ["(foo"]
+`,
+			expErr: `invalid issuance policy: [` +
+				`policies[0].namespaces: Required value: at least one namespace is required, ` +
+				`policies[0].maxDuration: Invalid value: "0s": must be greater than 0, ` +
+				`policies[1].namespaces[0]: Invalid value: "[a-": syntax error in pattern, ` +
+				"policies[1].allowedDNSNames[0]: Invalid value: \"(foo\": error parsing regexp: missing closing ): `^(?:(foo)$`]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			engine := NewEngine()
+			err := engine.Load([]byte(test.config))
+			if test.expErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expErr)
+				assert.ErrorIs(t, engine.Check(testBundle(nil)), ErrNotLoaded,
+					"an invalid policy should not be loaded")
+			}
+		})
+	}
+}
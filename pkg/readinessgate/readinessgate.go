@@ -16,10 +16,22 @@ limitations under the License.
 
 // Package readinessgate provides concrete implementations of
 // manager.ReadyToRequestFunc that defer certificate issuance until specific
-// pod-level conditions are met. Gate implementations cover the three main
-// sources of async pod state: IP assignment (pod-ip), status conditions
-// (pod-condition), and annotations (pod-annotation). Multiple gates are
-// combined with AND semantics via NewReadyToRequestFunc.
+// pod-level conditions are met. Gates read the pod state which is set
+// asynchronously after the pod is created: IP assignment (pod-ip), status
+// conditions (pod-condition), annotations (pod-annotation) and container
+// states (container-started, container-ready and init-containers-complete).
+// Any other condition over the pod may be given as a CEL expression
+// (pod-cel). Multiple gates are combined with AND semantics via
+// NewReadyToRequestFunc, except for gates in the same named group, which are
+// combined with any-of (OR) semantics. The gate specs are parsed by package
+// spec.
+//
+// Gates are set node-wide by --pod-readiness-gate, and per volume by the
+// csi.cert-manager.io/readiness-gates attribute, which may only use the gate
+// types the operator has allowed.
+//
+// A Timeout bounds how long volumes wait for their gates before they are
+// either issued a certificate anyway, or given up on.
 //
 // These implementations are intended to be upstreamed into csi-lib once
 // stabilised.
@@ -28,53 +40,76 @@ package readinessgate
 import (
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 
 	"github.com/cert-manager/csi-lib/manager"
 	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/lru"
 
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/readinessgate/spec"
 )
 
 // Gate tests a single condition on a pod. Returns (true, "") when satisfied,
 // or (false, reason) when the condition is not yet met.
 type Gate func(pod *corev1.Pod) (ready bool, reason string)
 
-// Parse parses gate specs of the form "<type>:<value>" into Gate functions.
-//
-// Each spec must be one of:
+// Parse parses gate specs of the form "[<group>=]<type>:<value>", as
+// described by spec.Parse, into Gate functions.
 //
-//	pod-ip:<family>                     family: any | ipv4 | ipv6
-//	pod-condition:<Type>[=<Status>]     Status defaults to "True"
-//	pod-annotation:<key>                annotation key must be present
+// Specs prefixed with the same group name are combined into a single Gate,
+// which passes when any of them passes, e.g. "ip=pod-ip:ipv6" and
+// "ip=pod-ip:ipv4". Gates are returned in the order each ungrouped spec or
+// group first appears.
 //
 // Returns an error if any spec is malformed or uses an unsupported type.
 func Parse(specs []string) ([]Gate, error) {
-	gates := make([]Gate, 0, len(specs))
-	for _, spec := range specs {
-		gate, err := parse(spec)
+	parsed := make([]spec.Spec, 0, len(specs))
+	for _, s := range specs {
+		p, err := spec.Parse(s)
 		if err != nil {
-			return nil, fmt.Errorf("invalid --pod-readiness-gate %q: %w", spec, err)
+			return nil, fmt.Errorf("invalid --pod-readiness-gate %q: %w", s, err)
 		}
-		gates = append(gates, gate)
+		parsed = append(parsed, p)
 	}
-	return gates, nil
+	return newGates(parsed), nil
 }
 
 // NewReadyToRequestFunc builds a manager.ReadyToRequestFunc that reads the pod
 // owning the volume from the provided node-scoped pod lister and evaluates all
-// gates against it. All gates must pass (AND semantics). Intended to be paired
-// with --continue-on-not-ready=true so that NodePublishVolume succeeds
-// immediately and cert issuance is retried asynchronously until all gates pass.
+// gates against it, followed by the gates of the volume's
+// csi.cert-manager.io/readiness-gates attribute. All gates must pass (AND
+// semantics). Intended to be paired with --continue-on-not-ready=true so that
+// NodePublishVolume succeeds immediately and cert issuance is retried
+// asynchronously until all gates pass.
+//
+// Volumes may only use the gate types in allowedVolumeGateTypes; volumes using
+// any other type are never ready. Volumes without any gates are ready without
+// reading the pod.
 //
 // The lister is expected to be backed by a shared informer scoped to the local
 // node via a spec.nodeName field selector. Reading from the informer cache
-// avoids a per-evaluation apiserver call.
-func NewReadyToRequestFunc(podLister corev1listers.PodLister, gates []Gate) manager.ReadyToRequestFunc {
+// avoids a per-evaluation apiserver call. The gates of each attribute value
+// are parsed once, and cached.
+func NewReadyToRequestFunc(podLister corev1listers.PodLister, gates []Gate, allowedVolumeGateTypes []string) manager.ReadyToRequestFunc {
+	volumeGateCache := newVolumeGateCache(allowedVolumeGateTypes)
 	return func(meta metadata.Metadata) (bool, string) {
+		volumeGates, err := volumeGateCache.gates(meta.VolumeContext[csiapi.ReadinessGatesKey])
+		if err != nil {
+			return false, err.Error()
+		}
+		if len(gates) == 0 && len(volumeGates) == 0 {
+			return true, ""
+		}
+
 		podName := meta.VolumeContext[csiapi.K8sVolumeContextKeyPodName]
 		podNamespace := meta.VolumeContext[csiapi.K8sVolumeContextKeyPodNamespace]
 		if podName == "" || podNamespace == "" {
@@ -93,7 +128,7 @@ func NewReadyToRequestFunc(podLister corev1listers.PodLister, gates []Gate) mana
 		}
 
 		var reasons []string
-		for _, gate := range gates {
+		for _, gate := range slices.Concat(gates, volumeGates) {
 			if ok, reason := gate(pod); !ok {
 				reasons = append(reasons, reason)
 			}
@@ -105,32 +140,147 @@ func NewReadyToRequestFunc(podLister corev1listers.PodLister, gates []Gate) mana
 	}
 }
 
-func parse(spec string) (Gate, error) {
-	kind, value, ok := strings.Cut(spec, ":")
-	if !ok || value == "" {
-		return nil, fmt.Errorf("expected <type>:<value>, got %q", spec)
+// All combines the given manager.ReadyToRequestFuncs with AND semantics. The
+// reasons of every function which is not ready are joined together.
+func All(funcs ...manager.ReadyToRequestFunc) manager.ReadyToRequestFunc {
+	return func(meta metadata.Metadata) (bool, string) {
+		var reasons []string
+		for _, fn := range funcs {
+			if ok, reason := fn(meta); !ok {
+				reasons = append(reasons, reason)
+			}
+		}
+		if len(reasons) > 0 {
+			return false, strings.Join(reasons, "; ")
+		}
+		return true, ""
+	}
+}
+
+// volumeGateCacheSize is the number of distinct readiness gates attribute
+// values whose gates are cached. Volumes of the same workload share a value,
+// so this comfortably exceeds the values in use on a node.
+const volumeGateCacheSize = 256
+
+// volumeGateCache caches the gates parsed from readiness gates attribute
+// values, so that the attribute is not re-parsed, and its CEL expressions
+// recompiled, every time a volume is checked.
+type volumeGateCache struct {
+	allowed sets.Set[string]
+
+	// cache holds a volumeGatesEntry for each attribute value.
+	cache *lru.Cache
+}
+
+// volumeGatesEntry is the result of parsing an attribute value. Errors are
+// cached too, since an invalid value is never going to become valid.
+type volumeGatesEntry struct {
+	gates []Gate
+	err   error
+}
+
+func newVolumeGateCache(allowedVolumeGateTypes []string) *volumeGateCache {
+	return &volumeGateCache{
+		allowed: sets.New(allowedVolumeGateTypes...),
+		cache:   lru.New(volumeGateCacheSize),
+	}
+}
+
+// gates returns the gates of a readiness gates attribute value, or an error
+// if the value is invalid or uses a gate type which is not allowed. Groups are
+// scoped to the attribute, so are never combined with the node's gates.
+func (c *volumeGateCache) gates(value string) ([]Gate, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if entry, ok := c.cache.Get(value); ok {
+		entry := entry.(volumeGatesEntry)
+		return entry.gates, entry.err
+	}
+
+	var entry volumeGatesEntry
+	specs, err := spec.ParseAttribute(value, c.allowed)
+	if err != nil {
+		entry.err = err
+	} else {
+		entry.gates = newGates(specs)
+	}
+	c.cache.Add(value, entry)
+	return entry.gates, entry.err
+}
+
+// newGates returns the Gates of the given specs, combining the gates of each
+// named group into one Gate with any-of semantics. Gates are returned in the
+// order each ungrouped spec or group first appears.
+func newGates(specs []spec.Spec) []Gate {
+	var gates []Gate
+	// groups holds the index in gates of a nil placeholder for each group,
+	// and members the group's gates.
+	groups := make(map[string]int)
+	members := make(map[string][]Gate)
+	for _, s := range specs {
+		gate := newGate(s)
+		if s.Group == "" {
+			gates = append(gates, gate)
+			continue
+		}
+		if _, ok := groups[s.Group]; !ok {
+			groups[s.Group] = len(gates)
+			gates = append(gates, nil)
+		}
+		members[s.Group] = append(members[s.Group], gate)
+	}
+	for group, i := range groups {
+		gates[i] = anyOf(group, members[group])
 	}
-	switch kind {
+	return gates
+}
+
+// anyOf combines the gates of a group, passing when any of them passes. When
+// none do, the reason of every gate is reported under the group's name.
+func anyOf(group string, gates []Gate) Gate {
+	return func(pod *corev1.Pod) (bool, string) {
+		reasons := make([]string, 0, len(gates))
+		for _, gate := range gates {
+			ok, reason := gate(pod)
+			if ok {
+				return true, ""
+			}
+			reasons = append(reasons, reason)
+		}
+		return false, fmt.Sprintf("group %q: %s", group, strings.Join(reasons, " | "))
+	}
+}
+
+// newGate returns the Gate of a parsed spec.
+func newGate(s spec.Spec) Gate {
+	switch s.Type {
 	case "pod-ip":
-		return podIPGate(value)
+		return podIPGate(s.Value)
 	case "pod-condition":
-		return podConditionGate(value)
+		return podConditionGate(s.Value)
 	case "pod-annotation":
-		return podAnnotationGate(value)
+		return podAnnotationGate(s.Value)
+	case "pod-cel":
+		return podCELGate(s.Value, s.Program)
+	case "container-started":
+		return containerStartedGate(s.Value)
+	case "container-ready":
+		return containerReadyGate(s.Value)
+	case "init-containers-complete":
+		return initContainersCompleteGate
 	default:
-		return nil, fmt.Errorf("unknown type %q; supported types: pod-ip, pod-condition, pod-annotation", kind)
+		// Unreachable, since spec.Parse rejects unknown types.
+		return func(*corev1.Pod) (bool, string) {
+			return false, fmt.Sprintf("unknown readiness gate type %q", s.Type)
+		}
 	}
 }
 
 // podIPGate defers issuance until pod.Status.PodIPs contains an address of the
 // requested family. Reads the CNI-populated field directly — no custom
 // controller needs to write anything.
-func podIPGate(family string) (Gate, error) {
-	switch family {
-	case "any", "ipv4", "ipv6":
-	default:
-		return nil, fmt.Errorf("pod-ip: unsupported family %q; use any, ipv4, or ipv6", family)
-	}
+func podIPGate(family string) Gate {
 	return func(pod *corev1.Pod) (bool, string) {
 		for _, podIP := range pod.Status.PodIPs {
 			if ipMatchesFamily(podIP.IP, family) {
@@ -138,32 +288,15 @@ func podIPGate(family string) (Gate, error) {
 			}
 		}
 		return false, fmt.Sprintf("pod has no %s address yet", family)
-	}, nil
+	}
 }
 
 // podConditionGate defers issuance until pod.Status.Conditions contains an
-// entry with the given type and status. Useful when an external controller
-// explicitly signals readiness via a pod condition.
-func podConditionGate(value string) (Gate, error) {
+// entry with the type and status of the given "<Type>=<Status>" value. Useful
+// when an external controller explicitly signals readiness via a pod
+// condition.
+func podConditionGate(value string) Gate {
 	condType, wantStatus, _ := strings.Cut(value, "=")
-	if condType == "" {
-		return nil, fmt.Errorf("pod-condition: condition type must not be empty")
-	}
-	if wantStatus == "" {
-		wantStatus = string(corev1.ConditionTrue)
-	} else {
-		// Accept "true", "TRUE", "True" — normalise to the canonical Kubernetes value.
-		switch strings.ToLower(wantStatus) {
-		case "true":
-			wantStatus = string(corev1.ConditionTrue)
-		case "false":
-			wantStatus = string(corev1.ConditionFalse)
-		case "unknown":
-			wantStatus = string(corev1.ConditionUnknown)
-		default:
-			return nil, fmt.Errorf("pod-condition: invalid status %q; must be True, False, or Unknown", wantStatus)
-		}
-	}
 	return func(pod *corev1.Pod) (bool, string) {
 		for _, c := range pod.Status.Conditions {
 			if string(c.Type) == condType {
@@ -174,22 +307,100 @@ func podConditionGate(value string) (Gate, error) {
 			}
 		}
 		return false, fmt.Sprintf("pod condition %q not yet present", condType)
-	}, nil
+	}
 }
 
 // podAnnotationGate defers issuance until a specific annotation key is present
 // on the pod. Useful for CNI plugins (e.g. Multus) that write network status
 // into pod annotations after attaching secondary interfaces.
-func podAnnotationGate(key string) (Gate, error) {
-	if key == "" {
-		return nil, fmt.Errorf("pod-annotation: annotation key must not be empty")
-	}
+func podAnnotationGate(key string) Gate {
 	return func(pod *corev1.Pod) (bool, string) {
 		if val, ok := pod.Annotations[key]; ok && val != "" {
 			return true, ""
 		}
 		return false, fmt.Sprintf("pod does not yet have annotation %q with a non-empty value", key)
-	}, nil
+	}
+}
+
+// containerStartedGate defers issuance until the named container has started,
+// i.e. is running and has passed its startup probe. Useful when a sidecar
+// must be up before the request is submitted, e.g. one which registers the
+// pod with an external CA. Sidecars run as restartable init containers are
+// also matched.
+func containerStartedGate(name string) Gate {
+	return func(pod *corev1.Pod) (bool, string) {
+		status, ok := containerStatus(pod, name)
+		if ok && status.Started != nil && *status.Started {
+			return true, ""
+		}
+		return false, fmt.Sprintf("container %q has not yet started", name)
+	}
+}
+
+// containerReadyGate defers issuance until the named container is ready, i.e.
+// has passed its readiness probe. Sidecars run as restartable init containers
+// are also matched.
+func containerReadyGate(name string) Gate {
+	return func(pod *corev1.Pod) (bool, string) {
+		status, ok := containerStatus(pod, name)
+		if ok && status.Ready {
+			return true, ""
+		}
+		return false, fmt.Sprintf("container %q is not yet ready", name)
+	}
+}
+
+// initContainersCompleteGate defers issuance until every init container of
+// the pod has completed successfully. Sidecars run as restartable init
+// containers never complete, so only need to have started.
+func initContainersCompleteGate(pod *corev1.Pod) (bool, string) {
+	for _, container := range pod.Spec.InitContainers {
+		status, ok := containerStatus(pod, container.Name)
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			if !ok || status.Started == nil || !*status.Started {
+				return false, fmt.Sprintf("init container %q has not yet started", container.Name)
+			}
+			continue
+		}
+		if !ok || status.State.Terminated == nil || status.State.Terminated.ExitCode != 0 {
+			return false, fmt.Sprintf("init container %q has not yet completed", container.Name)
+		}
+	}
+	return true, ""
+}
+
+// containerStatus returns the status of the named container, or init
+// container, of the pod.
+func containerStatus(pod *corev1.Pod, name string) (corev1.ContainerStatus, bool) {
+	for _, status := range slices.Concat(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses) {
+		if status.Name == name {
+			return status, true
+		}
+	}
+	return corev1.ContainerStatus{}, false
+}
+
+// podCELGate defers issuance until the given CEL expression, compiled by
+// spec.Parse, evaluates to true against the pod, for conditions which can't be
+// expressed by the other gate types, e.g. a label having a value and a volume
+// being mounted.
+func podCELGate(expression string, prg cel.Program) Gate {
+	return func(pod *corev1.Pod) (bool, string) {
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		if err != nil {
+			return false, fmt.Sprintf("pod does not yet satisfy `%s`: %v", expression, err)
+		}
+		val, _, err := prg.Eval(map[string]any{"object": object})
+		if err != nil {
+			// Fields which are not yet set, e.g. container statuses before
+			// the containers are created, fail evaluation with "no such key".
+			return false, fmt.Sprintf("pod does not yet satisfy `%s`: %v", expression, err)
+		}
+		if val != types.True {
+			return false, fmt.Sprintf("pod does not yet satisfy `%s`", expression)
+		}
+		return true, ""
+	}
 }
 
 func ipMatchesFamily(ip, family string) bool {
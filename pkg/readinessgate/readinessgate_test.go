@@ -17,8 +17,10 @@ limitations under the License.
 package readinessgate
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/cert-manager/csi-lib/manager"
 	"github.com/cert-manager/csi-lib/metadata"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -82,10 +84,62 @@ func Test_Parse(t *testing.T) {
 			specs:   []string{"pod-annotation:k8s.v1.cni.cncf.io/networks-status"},
 			wantLen: 1,
 		},
+		"valid pod-cel": {
+			specs:   []string{`pod-cel:object.metadata.labels["app"] == "foo"`},
+			wantLen: 1,
+		},
+		"valid pod-cel containing colons": {
+			specs:   []string{`pod-cel:object.metadata.annotations["example.com/phase"] == "a:b"`},
+			wantLen: 1,
+		},
+		"valid container-started": {
+			specs:   []string{"container-started:broker-agent"},
+			wantLen: 1,
+		},
+		"valid container-ready": {
+			specs:   []string{"container-ready:broker-agent"},
+			wantLen: 1,
+		},
+		"valid init-containers-complete": {
+			specs:   []string{"init-containers-complete"},
+			wantLen: 1,
+		},
+		"init-containers-complete in a group": {
+			specs:   []string{"init=init-containers-complete", "init=container-started:broker-agent"},
+			wantLen: 1,
+		},
+		"init-containers-complete with a value errors": {
+			specs:   []string{"init-containers-complete:all"},
+			wantErr: true,
+		},
+		"container-started empty name errors": {
+			specs:   []string{"container-started:"},
+			wantErr: true,
+		},
 		"multiple valid specs": {
 			specs:   []string{"pod-ip:ipv6", "pod-condition:Ready", "pod-annotation:my-key"},
 			wantLen: 3,
 		},
+		"gates in the same group are combined": {
+			specs:   []string{"ip=pod-ip:ipv6", "pod-condition:Ready", "ip=pod-ip:ipv4"},
+			wantLen: 2,
+		},
+		"gates in different groups are not combined": {
+			specs:   []string{"ip=pod-ip:ipv6", "network=pod-annotation:my-key", "network=pod-condition:Ready"},
+			wantLen: 2,
+		},
+		"pod-condition status is not mistaken for a group": {
+			specs:   []string{"pod-condition:Ready=True", "pod-condition:Ready=False"},
+			wantLen: 2,
+		},
+		"empty group name errors": {
+			specs:   []string{"=pod-ip:ipv6"},
+			wantErr: true,
+		},
+		"invalid gate in a group errors": {
+			specs:   []string{"ip=pod-ip:dual-stack"},
+			wantErr: true,
+		},
 		"missing colon errors": {
 			specs:   []string{"pod-ip"},
 			wantErr: true,
@@ -102,6 +156,18 @@ func Test_Parse(t *testing.T) {
 			specs:   []string{"pod-ip:dual-stack"},
 			wantErr: true,
 		},
+		"pod-cel invalid syntax errors": {
+			specs:   []string{"pod-cel:object.metadata.labels["},
+			wantErr: true,
+		},
+		"pod-cel undeclared variable errors": {
+			specs:   []string{`pod-cel:pod.metadata.name == "foo"`},
+			wantErr: true,
+		},
+		"pod-cel non-bool result errors": {
+			specs:   []string{`pod-cel:"foo"`},
+			wantErr: true,
+		},
 		"pod-condition empty type errors": {
 			specs:   []string{"pod-condition:=True"},
 			wantErr: true,
@@ -209,8 +275,7 @@ func Test_podIPGate(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			gate, err := podIPGate(tc.family)
-			require.NoError(t, err)
+			gate := podIPGate(tc.family)
 
 			pod := &corev1.Pod{Status: corev1.PodStatus{PodIPs: tc.podIPs}}
 			ready, reason := gate(pod)
@@ -223,11 +288,6 @@ func Test_podIPGate(t *testing.T) {
 	}
 }
 
-func Test_podIPGate_invalidFamily(t *testing.T) {
-	_, err := podIPGate("dual-stack")
-	assert.Error(t, err)
-}
-
 func Test_podConditionGate(t *testing.T) {
 	tests := map[string]struct {
 		spec       string
@@ -282,9 +342,11 @@ func Test_podConditionGate(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			gate, err := podConditionGate(tc.spec)
+			gates, err := Parse([]string{"pod-condition:" + tc.spec})
 			require.NoError(t, err)
+			require.Len(t, gates, 1)
 
+			gate := gates[0]
 			pod := &corev1.Pod{Status: corev1.PodStatus{Conditions: tc.conditions}}
 			ready, reason := gate(pod)
 
@@ -296,11 +358,6 @@ func Test_podConditionGate(t *testing.T) {
 	}
 }
 
-func Test_podConditionGate_emptyType(t *testing.T) {
-	_, err := podConditionGate("=True")
-	assert.Error(t, err)
-}
-
 // Status values from the flag must be matched case-insensitively against
 // corev1.ConditionStatus ("True", "False", "Unknown"). A user passing =true
 // or =TRUE should find the gate behaves identically to =True.
@@ -321,9 +378,10 @@ func Test_podConditionGate_caseInsensitiveStatus(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			gate, err := podConditionGate(tc.spec)
+			gates, err := Parse([]string{"pod-condition:" + tc.spec})
 			require.NoError(t, err)
-			ready, reason := gate(pod)
+			require.Len(t, gates, 1)
+			ready, reason := gates[0](pod)
 			assert.True(t, ready, "expected gate to pass but got reason: %s", reason)
 		})
 	}
@@ -363,8 +421,7 @@ func Test_podAnnotationGate(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			gate, err := podAnnotationGate(tc.key)
-			require.NoError(t, err)
+			gate := podAnnotationGate(tc.key)
 
 			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
 			ready, reason := gate(pod)
@@ -377,17 +434,194 @@ func Test_podAnnotationGate(t *testing.T) {
 	}
 }
 
-func Test_podAnnotationGate_emptyKey(t *testing.T) {
-	_, err := podAnnotationGate("")
-	assert.Error(t, err)
+func Test_containerGates(t *testing.T) {
+	started := true
+	notStarted := false
+	always := corev1.ContainerRestartPolicyAlways
+
+	tests := map[string]struct {
+		spec      string
+		pod       corev1.Pod
+		wantReady bool
+		wantMsg   string
+	}{
+		"container-started passes when the container has started": {
+			spec: "container-started:broker-agent",
+			pod: corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Started: &notStarted},
+				{Name: "broker-agent", Started: &started},
+			}}},
+			wantReady: true,
+		},
+		"container-started passes when a sidecar init container has started": {
+			spec: "container-started:broker-agent",
+			pod: corev1.Pod{Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "broker-agent", Started: &started},
+			}}},
+			wantReady: true,
+		},
+		"container-started fails when the container has not started": {
+			spec: "container-started:broker-agent",
+			pod: corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "broker-agent", Started: &notStarted},
+			}}},
+			wantMsg: `container "broker-agent" has not yet started`,
+		},
+		"container-started fails when the container has no status yet": {
+			spec:    "container-started:broker-agent",
+			pod:     corev1.Pod{},
+			wantMsg: `container "broker-agent" has not yet started`,
+		},
+		"container-ready passes when the container is ready": {
+			spec: "container-ready:broker-agent",
+			pod: corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "broker-agent", Started: &started, Ready: true},
+			}}},
+			wantReady: true,
+		},
+		"container-ready fails when the container has started but is not ready": {
+			spec: "container-ready:broker-agent",
+			pod: corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "broker-agent", Started: &started},
+			}}},
+			wantMsg: `container "broker-agent" is not yet ready`,
+		},
+		"init-containers-complete passes when the pod has no init containers": {
+			spec:      "init-containers-complete",
+			pod:       corev1.Pod{},
+			wantReady: true,
+		},
+		"init-containers-complete passes when every init container has completed and sidecars started": {
+			spec: "init-containers-complete",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{InitContainers: []corev1.Container{
+					{Name: "setup"},
+					{Name: "broker-agent", RestartPolicy: &always},
+				}},
+				Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "setup", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+					{Name: "broker-agent", Started: &started},
+				}},
+			},
+			wantReady: true,
+		},
+		"init-containers-complete fails when an init container is running": {
+			spec: "init-containers-complete",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "setup"}}},
+				Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "setup", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				}},
+			},
+			wantMsg: `init container "setup" has not yet completed`,
+		},
+		"init-containers-complete fails when an init container failed": {
+			spec: "init-containers-complete",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "setup"}}},
+				Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "setup", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+				}},
+			},
+			wantMsg: `init container "setup" has not yet completed`,
+		},
+		"init-containers-complete fails when a sidecar has not started": {
+			spec: "init-containers-complete",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "broker-agent", RestartPolicy: &always}}},
+				Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "broker-agent", Started: &notStarted},
+				}},
+			},
+			wantMsg: `init container "broker-agent" has not yet started`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gates, err := Parse([]string{tc.spec})
+			require.NoError(t, err)
+			require.Len(t, gates, 1)
+
+			ready, reason := gates[0](&tc.pod)
+			assert.Equal(t, tc.wantReady, ready)
+			assert.Equal(t, tc.wantMsg, reason)
+		})
+	}
+}
+
+func Test_podCELGate(t *testing.T) {
+	const sidecarStarted = `object.metadata.labels["app"] == "foo" && ` +
+		`object.status.containerStatuses.exists(c, c.name == "broker" && has(c.state.running))`
+
+	tests := map[string]struct {
+		expression string
+		pod        *corev1.Pod
+		wantReady  bool
+		wantMsg    string
+	}{
+		"passes when expression is true": {
+			expression: sidecarStarted,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "foo"}},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "broker", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				}},
+			},
+			wantReady: true,
+		},
+		"fails when expression is false": {
+			expression: sidecarStarted,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "foo"}},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "broker", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}},
+				}},
+			},
+			wantReady: false,
+			wantMsg:   "pod does not yet satisfy `" + sidecarStarted + "`",
+		},
+		"fails with evaluation error when fields are not yet set": {
+			expression: sidecarStarted,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "foo"}},
+			},
+			wantReady: false,
+			wantMsg:   "pod does not yet satisfy `" + sidecarStarted + "`: no such key: containerStatuses",
+		},
+		"passes when expression guards against unset fields": {
+			expression: `!has(object.metadata.annotations) || !("example.com/hold" in object.metadata.annotations)`,
+			pod:        &corev1.Pod{},
+			wantReady:  true,
+		},
+		"string extension functions are available": {
+			expression: `object.metadata.name.startsWith("web-")`,
+			pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0"}},
+			wantReady:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gates, err := Parse([]string{"pod-cel:" + tc.expression})
+			require.NoError(t, err)
+			require.Len(t, gates, 1)
+
+			gate := gates[0]
+			ready, reason := gate(tc.pod)
+			assert.Equal(t, tc.wantReady, ready)
+			if !tc.wantReady {
+				assert.Equal(t, tc.wantMsg, reason)
+			}
+		})
+	}
 }
 
 // Multus and some CNI plugins write the annotation key immediately with an
 // empty value and fill it in asynchronously once the interface is attached.
 // The gate must not pass until the annotation has a non-empty value.
 func Test_podAnnotationGate_emptyValueShouldNotPass(t *testing.T) {
-	gate, err := podAnnotationGate("k8s.v1.cni.cncf.io/networks-status")
-	require.NoError(t, err)
+	gate := podAnnotationGate("k8s.v1.cni.cncf.io/networks-status")
 
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -423,12 +657,23 @@ func Test_NewReadyToRequestFunc(t *testing.T) {
 		Status:     corev1.PodStatus{},
 	}
 
+	withVolumeGates := func(gates string) metadata.Metadata {
+		return metadata.Metadata{
+			VolumeContext: map[string]string{
+				csiapi.K8sVolumeContextKeyPodName:      podName,
+				csiapi.K8sVolumeContextKeyPodNamespace: podNamespace,
+				csiapi.ReadinessGatesKey:               gates,
+			},
+		}
+	}
+
 	tests := map[string]struct {
-		meta       metadata.Metadata
-		pod        *corev1.Pod
-		specs      []string
-		wantReady  bool
-		wantReason string
+		meta         metadata.Metadata
+		pod          *corev1.Pod
+		specs        []string
+		allowedTypes []string
+		wantReady    bool
+		wantReason   string
 	}{
 		"missing pod name in VolumeContext returns false": {
 			meta: metadata.Metadata{
@@ -516,6 +761,90 @@ func Test_NewReadyToRequestFunc(t *testing.T) {
 			wantReady:  false,
 			wantReason: `pod has no ipv6 address yet; pod does not yet have annotation "missing-annotation" with a non-empty value`,
 		},
+		"no gates is ready without reading the pod": {
+			meta:      validMeta,
+			pod:       nil,
+			wantReady: true,
+		},
+		"allowed volume gate passes": {
+			meta:         withVolumeGates("pod-ip:ipv6"),
+			pod:          podWithIPv6,
+			allowedTypes: []string{"pod-ip"},
+			wantReady:    true,
+		},
+		"allowed volume gate fails": {
+			meta:         withVolumeGates("pod-ip:ipv6"),
+			pod:          podNoIPs,
+			allowedTypes: []string{"pod-ip"},
+			wantReady:    false,
+			wantReason:   "pod has no ipv6 address yet",
+		},
+		"volume gates are evaluated after node gates": {
+			meta:         withVolumeGates("pod-annotation:missing-annotation; pod-ip:any"),
+			pod:          podNoIPs,
+			specs:        []string{"pod-ip:ipv6"},
+			allowedTypes: []string{"pod-ip", "pod-annotation"},
+			wantReady:    false,
+			wantReason:   `pod has no ipv6 address yet; pod does not yet have annotation "missing-annotation" with a non-empty value; pod has no any address yet`,
+		},
+		"volume gate of a type which is not allowed is never ready": {
+			meta:         withVolumeGates("pod-condition:Ready"),
+			pod:          podWithIPv6,
+			allowedTypes: []string{"pod-annotation", "pod-ip"},
+			wantReady:    false,
+			wantReason:   `readiness gate "pod-condition:Ready" is not allowed: volumes may only use the types [pod-annotation pod-ip]`,
+		},
+		"volume gate with no allowed types is never ready": {
+			meta:       withVolumeGates("pod-ip:ipv6"),
+			pod:        podWithIPv6,
+			wantReady:  false,
+			wantReason: `readiness gate "pod-ip:ipv6" is not allowed: volumes may only use the types []`,
+		},
+		"grouped node gates pass when any gate passes": {
+			meta:      validMeta,
+			pod:       podWithIPv6,
+			specs:     []string{"ip=pod-ip:ipv4", "ip=pod-ip:ipv6"},
+			wantReady: true,
+		},
+		"grouped node gates report reasons per group": {
+			meta:       validMeta,
+			pod:        podNoIPs,
+			specs:      []string{"ip=pod-ip:ipv6", "pod-annotation:missing-annotation", "ip=pod-ip:ipv4", "network=pod-condition:Ready"},
+			wantReady:  false,
+			wantReason: `group "ip": pod has no ipv6 address yet | pod has no ipv4 address yet; pod does not yet have annotation "missing-annotation" with a non-empty value; group "network": pod condition "Ready" not yet present`,
+		},
+		"grouped volume gates pass when any gate passes": {
+			meta: withVolumeGates("network=pod-annotation:k8s.v1.cni.cncf.io/network-status;network=pod-condition:Ready"),
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: podNamespace},
+				Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+				}},
+			},
+			allowedTypes: []string{"pod-annotation", "pod-condition"},
+			wantReady:    true,
+		},
+		"grouped volume gate of a type which is not allowed is never ready": {
+			meta:         withVolumeGates("ip=pod-ip:ipv6;ip=pod-condition:Ready"),
+			pod:          podWithIPv6,
+			allowedTypes: []string{"pod-ip"},
+			wantReady:    false,
+			wantReason:   `readiness gate "ip=pod-condition:Ready" is not allowed: volumes may only use the types [pod-ip]`,
+		},
+		"volume gate without a value is checked against the allowed types": {
+			meta:         withVolumeGates("init-containers-complete; container-started:broker-agent"),
+			pod:          podWithIPv6,
+			allowedTypes: []string{"container-started"},
+			wantReady:    false,
+			wantReason:   `readiness gate "init-containers-complete" is not allowed: volumes may only use the types [container-started]`,
+		},
+		"invalid volume gate is never ready": {
+			meta:         withVolumeGates("pod-ip:dual-stack"),
+			pod:          podWithIPv6,
+			allowedTypes: []string{"pod-ip"},
+			wantReady:    false,
+			wantReason:   `invalid readiness gate "pod-ip:dual-stack": pod-ip: unsupported family "dual-stack"; use any, ipv4, or ipv6`,
+		},
 	}
 
 	for name, tc := range tests {
@@ -525,7 +854,7 @@ func Test_NewReadyToRequestFunc(t *testing.T) {
 			gates, err := Parse(tc.specs)
 			require.NoError(t, err)
 
-			fn := NewReadyToRequestFunc(podLister, gates)
+			fn := NewReadyToRequestFunc(podLister, gates, tc.allowedTypes)
 			ready, reason := fn(tc.meta)
 			assert.Equal(t, tc.wantReady, ready)
 			if tc.wantReason != "" {
@@ -534,3 +863,74 @@ func Test_NewReadyToRequestFunc(t *testing.T) {
 		})
 	}
 }
+
+func Test_volumeGateCache(t *testing.T) {
+	const (
+		celGates   = `pod-annotation:example.com/ready;pod-cel:object.metadata.name.startsWith("web-")`
+		notAllowed = "pod-condition:Ready"
+	)
+
+	c := newVolumeGateCache([]string{"pod-annotation", "pod-cel"})
+
+	gates, err := c.gates("")
+	require.NoError(t, err)
+	assert.Empty(t, gates)
+	assert.Equal(t, 0, c.cache.Len(), "an empty value should not be cached")
+
+	first, err := c.gates(celGates)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	second, err := c.gates(celGates)
+	require.NoError(t, err)
+	require.Len(t, second, 2)
+	for i := range first {
+		assert.Equal(t, reflect.ValueOf(first[i]).Pointer(), reflect.ValueOf(second[i]).Pointer(),
+			"the cached gates should be returned rather than parsed again")
+	}
+	assert.Equal(t, 1, c.cache.Len())
+
+	_, err = c.gates(notAllowed)
+	assert.EqualError(t, err, `readiness gate "pod-condition:Ready" is not allowed: volumes may only use the types [pod-annotation pod-cel]`)
+	_, err = c.gates(notAllowed)
+	assert.EqualError(t, err, `readiness gate "pod-condition:Ready" is not allowed: volumes may only use the types [pod-annotation pod-cel]`)
+	assert.Equal(t, 2, c.cache.Len(), "errors should be cached")
+}
+
+func Test_All(t *testing.T) {
+	ready := func(metadata.Metadata) (bool, string) { return true, "" }
+	notReady := func(reason string) manager.ReadyToRequestFunc {
+		return func(metadata.Metadata) (bool, string) { return false, reason }
+	}
+
+	tests := map[string]struct {
+		funcs      []manager.ReadyToRequestFunc
+		wantReady  bool
+		wantReason string
+	}{
+		"no funcs is ready": {
+			wantReady: true,
+		},
+		"all ready is ready": {
+			funcs:     []manager.ReadyToRequestFunc{ready, ready},
+			wantReady: true,
+		},
+		"one not ready is not ready": {
+			funcs:      []manager.ReadyToRequestFunc{ready, notReady("a")},
+			wantReady:  false,
+			wantReason: "a",
+		},
+		"all not ready returns all reasons": {
+			funcs:      []manager.ReadyToRequestFunc{notReady("a"), ready, notReady("b")},
+			wantReady:  false,
+			wantReason: "a; b",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ok, reason := All(test.funcs...)(metadata.Metadata{})
+			assert.Equal(t, test.wantReady, ok)
+			assert.Equal(t, test.wantReason, reason)
+		})
+	}
+}
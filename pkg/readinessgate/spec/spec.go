@@ -0,0 +1,263 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spec parses the readiness gate specs given by --pod-readiness-gate
+// and the csi.cert-manager.io/readiness-gates volume attribute. It only
+// depends on cel-go, to compile pod-cel expressions, so that attributes may be
+// validated without csi-lib or the pod informer. The parsed gates are
+// evaluated against pods by package readinessgate.
+package spec
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/lru"
+)
+
+// Types are the supported gate types.
+var Types = []string{"pod-ip", "pod-condition", "pod-annotation", "pod-cel",
+	"container-started", "container-ready", "init-containers-complete"}
+
+// Spec is a parsed gate spec.
+type Spec struct {
+	// Group is the name of the group the gate is in, or empty if the gate is
+	// not grouped.
+	Group string
+
+	// Type is the gate type, one of Types.
+	Type string
+
+	// Value is the value of the gate, which is empty for
+	// init-containers-complete. pod-condition values are normalised to
+	// "<Type>=<Status>", with the canonical Kubernetes status.
+	Value string
+
+	// Program is the compiled expression of a pod-cel gate.
+	Program cel.Program
+}
+
+// Parse parses a gate spec of the form "[<group>=]<type>:<value>".
+//
+// The spec must be one of:
+//
+//	pod-ip:<family>                     family: any | ipv4 | ipv6
+//	pod-condition:<Type>[=<Status>]     Status defaults to "True"
+//	pod-annotation:<key>                annotation key must be present
+//	pod-cel:<expression>                CEL expression over the pod must be true
+//	container-started:<name>            container has started
+//	container-ready:<name>              container is ready
+//	init-containers-complete            init containers have completed
+//
+// CEL expressions are compiled when parsed, and refer to the pod as
+// "object", e.g. object.metadata.labels["app"] == "foo".
+//
+// Returns an error if the spec is malformed or uses an unsupported type.
+func Parse(spec string) (Spec, error) {
+	group, gateSpec := SplitGroup(spec)
+	s, err := parse(gateSpec)
+	if err != nil {
+		return Spec{}, err
+	}
+	if gateSpec != spec && group == "" {
+		return Spec{}, fmt.Errorf("group name must not be empty")
+	}
+	s.Group = group
+	return s, nil
+}
+
+// ParseAttribute parses the semicolon separated gate specs of a
+// csi.cert-manager.io/readiness-gates volume attribute, of the same form as
+// Parse. If allowedTypes is non-nil, specs of any other type are rejected.
+// Since gates are separated by semicolons, pod-cel expressions in the
+// attribute may not contain them.
+func ParseAttribute(value string, allowedTypes sets.Set[string]) ([]Spec, error) {
+	var specs []Spec
+	for spec := range strings.SplitSeq(value, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		_, gateSpec := SplitGroup(spec)
+		kind, _, _ := strings.Cut(gateSpec, ":")
+		if allowedTypes != nil && !allowedTypes.Has(kind) {
+			return nil, fmt.Errorf("readiness gate %q is not allowed: volumes may only use the types %v", spec, sets.List(allowedTypes))
+		}
+		s, err := Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid readiness gate %q: %w", spec, err)
+		}
+		specs = append(specs, s)
+	}
+	return specs, nil
+}
+
+// ValidateTypes returns an error if any of the given gate types is not
+// supported.
+func ValidateTypes(types []string) error {
+	for _, kind := range types {
+		if !slices.Contains(Types, kind) {
+			return fmt.Errorf("unknown type %q; supported types: %s", kind, strings.Join(Types, ", "))
+		}
+	}
+	return nil
+}
+
+// SplitGroup splits the optional "<group>=" prefix from a gate spec. The
+// prefix is only recognised before the first ':', so that values containing
+// '=', e.g. "pod-condition:Ready=True", are never mistaken for a group.
+func SplitGroup(spec string) (group, gateSpec string) {
+	kind, _, _ := strings.Cut(spec, ":")
+	group, _, ok := strings.Cut(kind, "=")
+	if !ok {
+		return "", spec
+	}
+	return group, spec[len(group)+1:]
+}
+
+func parse(spec string) (Spec, error) {
+	kind, value, ok := strings.Cut(spec, ":")
+	if kind == "init-containers-complete" {
+		if ok {
+			return Spec{}, fmt.Errorf("init-containers-complete: takes no value, got %q", value)
+		}
+		return Spec{Type: kind}, nil
+	}
+	if !ok || value == "" {
+		return Spec{}, fmt.Errorf("expected <type>:<value>, got %q", spec)
+	}
+
+	s := Spec{Type: kind, Value: value}
+	var err error
+	switch kind {
+	case "pod-ip":
+		err = validatePodIPFamily(value)
+	case "pod-condition":
+		s.Value, err = normalisePodCondition(value)
+	case "pod-annotation":
+		// Any non-empty annotation key is accepted.
+	case "pod-cel":
+		s.Program, err = compileCEL(value)
+	case "container-started", "container-ready":
+		// Any non-empty container name is accepted.
+	default:
+		err = fmt.Errorf("unknown type %q; supported types: %s", kind, strings.Join(Types, ", "))
+	}
+	if err != nil {
+		return Spec{}, err
+	}
+	return s, nil
+}
+
+func validatePodIPFamily(family string) error {
+	switch family {
+	case "any", "ipv4", "ipv6":
+		return nil
+	default:
+		return fmt.Errorf("pod-ip: unsupported family %q; use any, ipv4, or ipv6", family)
+	}
+}
+
+// normalisePodCondition returns the "<Type>[=<Status>]" value of a
+// pod-condition gate as "<Type>=<Status>", with the status defaulted to True
+// and matched case-insensitively.
+func normalisePodCondition(value string) (string, error) {
+	condType, status, _ := strings.Cut(value, "=")
+	if condType == "" {
+		return "", fmt.Errorf("pod-condition: condition type must not be empty")
+	}
+	// Accept "true", "TRUE", "True" — normalise to the canonical Kubernetes value.
+	switch strings.ToLower(status) {
+	case "", "true":
+		status = "True"
+	case "false":
+		status = "False"
+	case "unknown":
+		status = "Unknown"
+	default:
+		return "", fmt.Errorf("pod-condition: invalid status %q; must be True, False, or Unknown", status)
+	}
+	return condType + "=" + status, nil
+}
+
+// celCostLimit bounds the runtime cost of evaluating a pod-cel expression, so
+// that an expression requested by a volume cannot stall the driver.
+const celCostLimit = 1_000_000
+
+// celEnv is the CEL environment pod-cel expressions are compiled in, with the
+// pod declared as the variable "object".
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		ext.Strings(),
+	)
+})
+
+// celProgramCacheSize is the number of distinct pod-cel expressions whose
+// programs are cached. Volumes of the same workload share expressions, so this
+// comfortably exceeds the expressions in use on a node.
+const celProgramCacheSize = 256
+
+// celPrograms caches a celProgramEntry for each pod-cel expression, since
+// volume attributes are validated every time a volume is checked for
+// readiness.
+var celPrograms = lru.New(celProgramCacheSize)
+
+// celProgramEntry is the result of compiling an expression. Errors are cached
+// too, since an invalid expression is never going to become valid.
+type celProgramEntry struct {
+	prg cel.Program
+	err error
+}
+
+// compileCEL compiles and type checks a pod-cel expression, so invalid
+// programs are rejected up front rather than on every evaluation. Programs are
+// cached, so each expression is only compiled once.
+func compileCEL(expression string) (cel.Program, error) {
+	if entry, ok := celPrograms.Get(expression); ok {
+		entry := entry.(celProgramEntry)
+		return entry.prg, entry.err
+	}
+
+	prg, err := newCELProgram(expression)
+	celPrograms.Add(expression, celProgramEntry{prg: prg, err: err})
+	return prg, err
+}
+
+// newCELProgram compiles and type checks a pod-cel expression.
+func newCELProgram(expression string) (cel.Program, error) {
+	env, err := celEnv()
+	if err != nil {
+		return nil, fmt.Errorf("pod-cel: failed to create CEL environment: %w", err)
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("pod-cel: invalid expression: %w", issues.Err())
+	}
+	if outputType := ast.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("pod-cel: expression must evaluate to a bool, got %s", outputType)
+	}
+	prg, err := env.Program(ast, cel.CostLimit(celCostLimit))
+	if err != nil {
+		return nil, fmt.Errorf("pod-cel: invalid expression: %w", err)
+	}
+	return prg, nil
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := map[string]struct {
+		spec    string
+		want    Spec
+		wantErr string
+	}{
+		"pod-ip gate": {
+			spec: "pod-ip:ipv6",
+			want: Spec{Type: "pod-ip", Value: "ipv6"},
+		},
+		"grouped gate": {
+			spec: "ip=pod-ip:ipv6",
+			want: Spec{Group: "ip", Type: "pod-ip", Value: "ipv6"},
+		},
+		"pod-condition status defaults to True": {
+			spec: "pod-condition:Ready",
+			want: Spec{Type: "pod-condition", Value: "Ready=True"},
+		},
+		"pod-condition status is normalised": {
+			spec: "pod-condition:Degraded=false",
+			want: Spec{Type: "pod-condition", Value: "Degraded=False"},
+		},
+		"init-containers-complete has no value": {
+			spec: "init-containers-complete",
+			want: Spec{Type: "init-containers-complete"},
+		},
+		"empty group errors": {
+			spec:    "=pod-ip:ipv6",
+			wantErr: "group name must not be empty",
+		},
+		"unsupported pod-ip family errors": {
+			spec:    "pod-ip:dual-stack",
+			wantErr: `pod-ip: unsupported family "dual-stack"; use any, ipv4, or ipv6`,
+		},
+		"empty pod-condition type errors": {
+			spec:    "pod-condition:=True",
+			wantErr: "pod-condition: condition type must not be empty",
+		},
+		"invalid pod-condition status errors": {
+			spec:    "pod-condition:Ready=Maybe",
+			wantErr: `pod-condition: invalid status "Maybe"; must be True, False, or Unknown`,
+		},
+		"empty pod-annotation key errors": {
+			spec:    "pod-annotation:",
+			wantErr: `expected <type>:<value>, got "pod-annotation:"`,
+		},
+		"init-containers-complete with a value errors": {
+			spec:    "init-containers-complete:all",
+			wantErr: `init-containers-complete: takes no value, got "all"`,
+		},
+		"unknown type errors": {
+			spec:    "pod-label:app=foo",
+			wantErr: `unknown type "pod-label"; supported types: pod-ip, pod-condition, pod-annotation, pod-cel, container-started, container-ready, init-containers-complete`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.spec)
+			if len(tc.wantErr) > 0 {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_Parse_podCEL(t *testing.T) {
+	got, err := Parse(`app=pod-cel:object.metadata.labels["app"] == "foo"`)
+	require.NoError(t, err)
+	assert.Equal(t, "app", got.Group)
+	assert.Equal(t, "pod-cel", got.Type)
+	assert.Equal(t, `object.metadata.labels["app"] == "foo"`, got.Value)
+	assert.NotNil(t, got.Program)
+}
+
+func Test_ParseAttribute(t *testing.T) {
+	tests := map[string]struct {
+		value        string
+		allowedTypes sets.Set[string]
+		wantLen      int
+		wantErr      string
+	}{
+		"empty value returns no gates": {
+			value:   "",
+			wantLen: 0,
+		},
+		"single gate": {
+			value:   "pod-annotation:k8s.v1.cni.cncf.io/network-status",
+			wantLen: 1,
+		},
+		"gate without a value": {
+			value:   "init-containers-complete;container-ready:broker-agent",
+			wantLen: 2,
+		},
+		"multiple gates separated by semicolons, ignoring whitespace and empty entries": {
+			value:   "pod-ip:ipv6; pod-condition:Ready=True;",
+			wantLen: 2,
+		},
+		"invalid gate errors": {
+			value:   "pod-ip:ipv6;pod-label:app=foo",
+			wantErr: `invalid readiness gate "pod-label:app=foo": unknown type "pod-label"; supported types: pod-ip, pod-condition, pod-annotation, pod-cel, container-started, container-ready, init-containers-complete`,
+		},
+		"allowed types are accepted": {
+			value:        "pod-ip:ipv6;net=pod-annotation:k8s.v1.cni.cncf.io/network-status",
+			allowedTypes: sets.New("pod-ip", "pod-annotation"),
+			wantLen:      2,
+		},
+		"types which are not allowed error": {
+			value:        "pod-ip:ipv6;pod-condition:Ready",
+			allowedTypes: sets.New("pod-ip"),
+			wantErr:      `readiness gate "pod-condition:Ready" is not allowed: volumes may only use the types [pod-ip]`,
+		},
+		"an empty set of allowed types rejects every gate": {
+			value:        "pod-ip:ipv6",
+			allowedTypes: sets.New[string](),
+			wantErr:      `readiness gate "pod-ip:ipv6" is not allowed: volumes may only use the types []`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			specs, err := ParseAttribute(tc.value, tc.allowedTypes)
+			if len(tc.wantErr) > 0 {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, specs, tc.wantLen)
+		})
+	}
+}
+
+func Test_SplitGroup(t *testing.T) {
+	tests := map[string]struct {
+		spec         string
+		wantGroup    string
+		wantGateSpec string
+	}{
+		"ungrouped spec": {
+			spec:         "pod-ip:ipv6",
+			wantGateSpec: "pod-ip:ipv6",
+		},
+		"grouped spec": {
+			spec:         "ip=pod-ip:ipv6",
+			wantGroup:    "ip",
+			wantGateSpec: "pod-ip:ipv6",
+		},
+		"equals in the value is not a group": {
+			spec:         "pod-condition:Ready=True",
+			wantGateSpec: "pod-condition:Ready=True",
+		},
+		"grouped spec with equals in the value": {
+			spec:         "ready=pod-condition:Ready=True",
+			wantGroup:    "ready",
+			wantGateSpec: "pod-condition:Ready=True",
+		},
+		"grouped CEL spec": {
+			spec:         `app=pod-cel:object.metadata.labels["app"] == "foo"`,
+			wantGroup:    "app",
+			wantGateSpec: `pod-cel:object.metadata.labels["app"] == "foo"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			group, gateSpec := SplitGroup(tc.spec)
+			assert.Equal(t, tc.wantGroup, group)
+			assert.Equal(t, tc.wantGateSpec, gateSpec)
+		})
+	}
+}
+
+func Test_ValidateTypes(t *testing.T) {
+	assert.NoError(t, ValidateTypes(nil))
+	assert.NoError(t, ValidateTypes([]string{"pod-ip", "pod-annotation"}))
+	assert.EqualError(t, ValidateTypes([]string{"pod-ip", "pod-label"}),
+		`unknown type "pod-label"; supported types: pod-ip, pod-condition, pod-annotation, pod-cel, container-started, container-ready, init-containers-complete`)
+}
+
+func Test_compileCEL(t *testing.T) {
+	tests := map[string]struct {
+		expression string
+		wantErr    string
+	}{
+		"bool expression": {
+			expression: `object.metadata.name.startsWith("web-")`,
+		},
+		"syntax error": {
+			expression: `object.metadata.labels[`,
+			wantErr:    "pod-cel: invalid expression: ",
+		},
+		"undeclared reference": {
+			expression: `pod.metadata.name == "foo"`,
+			wantErr:    "pod-cel: invalid expression: ",
+		},
+		"non-bool result": {
+			expression: `object.metadata.name + "foo" == "foo" ? 1 : 2`,
+			wantErr:    "pod-cel: expression must evaluate to a bool, got int",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			prg, err := compileCEL(tc.expression)
+			if len(tc.wantErr) > 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, prg)
+		})
+	}
+}
+
+func Test_compileCEL_cached(t *testing.T) {
+	expression := `object.metadata.name == "cached"`
+	prg, err := compileCEL(expression)
+	require.NoError(t, err)
+
+	cached, err := compileCEL(expression)
+	require.NoError(t, err)
+	assert.Same(t, prg, cached)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readinessgate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/csi-lib/manager"
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/cert-manager/csi-lib/storage"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+)
+
+// TimeoutPolicy is what happens to a volume whose readiness gates have not
+// passed within the timeout.
+type TimeoutPolicy string
+
+const (
+	// TimeoutPolicyIssue requests the certificate anyway, without the
+	// csi.cert-manager.io/ip-sans-from-pod IPs which the pod has not yet been
+	// assigned, once the volume is Issuable.
+	TimeoutPolicyIssue TimeoutPolicy = "issue"
+
+	// TimeoutPolicyFail gives up on the volume, which is never issued a
+	// certificate.
+	TimeoutPolicyFail TimeoutPolicy = "fail"
+)
+
+// TimeoutPolicies are the supported timeout policies.
+var TimeoutPolicies = []TimeoutPolicy{TimeoutPolicyIssue, TimeoutPolicyFail}
+
+// publishTimeKey is the volume context key the time the volume was published
+// is persisted under, so that the timeout survives driver restarts.
+const publishTimeKey = "readinessgate.csi.cert-manager.io/publish-time"
+
+// MetadataStore reads and writes the metadata of volumes.
+type MetadataStore interface {
+	storage.MetadataReader
+	WriteMetadata(volumeID string, meta metadata.Metadata) error
+}
+
+// Timeout bounds how long a volume waits to be ready before its first
+// certificate is requested, measured from when the volume was published.
+// Volumes which have already been issued a certificate wait to be ready
+// indefinitely when renewing.
+//
+// csi-lib checks whether a volume is ready as soon as it is published, so the
+// publish time is taken as the time the volume was first checked. It is
+// persisted in the volume's metadata, so the timeout isn't restarted when the
+// driver is.
+type Timeout struct {
+	volumes  MetadataStore
+	clock    clock.PassiveClock
+	duration time.Duration
+	policy   TimeoutPolicy
+
+	// OnTimeout, if set, is called once for each volume which times out,
+	// with the reason the volume was last not ready.
+	OnTimeout func(meta metadata.Metadata, reason string)
+
+	// Issuable, if set, is called for volumes which have timed out with
+	// TimeoutPolicyIssue, to check that their certificate can be requested
+	// without what they were waiting for. For example, a volume expanding the
+	// POD_IP variable can't be requested until the pod has IPs, so isn't
+	// ready until it can be.
+	Issuable manager.ReadyToRequestFunc
+
+	lock sync.Mutex
+	// firstChecked holds the time each volume waiting for its first
+	// certificate was first checked.
+	firstChecked map[string]time.Time
+	// timedOut holds the volumes which have timed out.
+	timedOut sets.Set[string]
+}
+
+// NewTimeout constructs a new Timeout, after which volumes are handled
+// according to the given policy. The volumes store is used to persist the
+// publish time of volumes, and to forget state about volumes which have since
+// been removed.
+func NewTimeout(volumes MetadataStore, clock clock.PassiveClock, duration time.Duration, policy TimeoutPolicy) (*Timeout, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("timeout must be > 0, got %s", duration)
+	}
+	if !slices.Contains(TimeoutPolicies, policy) {
+		return nil, fmt.Errorf("unknown timeout policy %q; supported policies: %v", policy, TimeoutPolicies)
+	}
+	return &Timeout{
+		volumes:      volumes,
+		clock:        clock,
+		duration:     duration,
+		policy:       policy,
+		firstChecked: make(map[string]time.Time),
+		timedOut:     sets.New[string](),
+	}, nil
+}
+
+// Duration returns how long volumes wait to be ready before timing out.
+func (t *Timeout) Duration() time.Duration {
+	return t.duration
+}
+
+// Policy returns what happens to volumes which time out.
+func (t *Timeout) Policy() TimeoutPolicy {
+	return t.policy
+}
+
+// ReadyToRequest wraps the given manager.ReadyToRequestFunc, so that volumes
+// which are not ready within the timeout are ready with TimeoutPolicyIssue,
+// once Issuable, or are never ready with TimeoutPolicyFail. Once a volume has
+// timed out, the wrapped function is no longer called for it.
+func (t *Timeout) ReadyToRequest(ready manager.ReadyToRequestFunc) manager.ReadyToRequestFunc {
+	return func(meta metadata.Metadata) (bool, string) {
+		// The metadata only has a NextIssuanceTime once a certificate has
+		// been written to the volume.
+		if meta.NextIssuanceTime != nil {
+			t.forget(meta.VolumeID)
+			return ready(meta)
+		}
+
+		t.lock.Lock()
+		timedOut := t.timedOut.Has(meta.VolumeID)
+		t.lock.Unlock()
+		if timedOut {
+			return t.readyAfterTimeout(meta)
+		}
+
+		ok, reason := ready(meta)
+		if ok || t.clock.Since(t.publishTime(meta)) < t.duration {
+			return ok, reason
+		}
+
+		t.lock.Lock()
+		t.timedOut.Insert(meta.VolumeID)
+		t.lock.Unlock()
+		if t.OnTimeout != nil {
+			t.OnTimeout(meta, reason)
+		}
+		return t.readyAfterTimeout(meta)
+	}
+}
+
+// IssueWithoutPodIPs returns true if the volume has timed out with
+// TimeoutPolicyIssue, so its certificate should be requested without the pod
+// IPs which have not yet been assigned.
+func (t *Timeout) IssueWithoutPodIPs(meta metadata.Metadata) bool {
+	if t.policy != TimeoutPolicyIssue || meta.NextIssuanceTime != nil {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.timedOut.Has(meta.VolumeID)
+}
+
+func (t *Timeout) readyAfterTimeout(meta metadata.Metadata) (bool, string) {
+	if t.policy != TimeoutPolicyIssue {
+		return false, fmt.Sprintf("gave up waiting for the volume to be ready after %s", t.duration)
+	}
+	if t.Issuable != nil {
+		if ok, reason := t.Issuable(meta); !ok {
+			return false, fmt.Sprintf("timed out waiting for the volume to be ready after %s, but can't issue without: %s", t.duration, reason)
+		}
+	}
+	return true, ""
+}
+
+// publishTime returns the time the volume is taken to have been published,
+// being the time it was first checked. The time is read from the volume's
+// metadata if it was persisted when the volume was first checked.
+func (t *Timeout) publishTime(meta metadata.Metadata) time.Time {
+	if publishTime, err := time.Parse(time.RFC3339Nano, meta.VolumeContext[publishTimeKey]); err == nil {
+		return publishTime
+	}
+
+	t.lock.Lock()
+	publishTime, ok := t.firstChecked[meta.VolumeID]
+	if !ok {
+		publishTime = t.clock.Now()
+		t.firstChecked[meta.VolumeID] = publishTime
+	}
+	t.lock.Unlock()
+
+	if !ok {
+		t.persist(meta, publishTime)
+		t.prune(meta.VolumeID)
+	}
+	return publishTime
+}
+
+// persist writes the publish time to the volume's metadata. The time is still
+// held in memory if it can't be written, so the timeout is only restarted
+// along with the driver.
+func (t *Timeout) persist(meta metadata.Metadata, publishTime time.Time) {
+	if t.volumes == nil {
+		return
+	}
+	meta.VolumeContext = maps.Clone(meta.VolumeContext)
+	if meta.VolumeContext == nil {
+		meta.VolumeContext = make(map[string]string)
+	}
+	meta.VolumeContext[publishTimeKey] = publishTime.Format(time.RFC3339Nano)
+	_ = t.volumes.WriteMetadata(meta.VolumeID, meta)
+}
+
+// forget removes the state held about the volume.
+func (t *Timeout) forget(volumeID string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.firstChecked, volumeID)
+	t.timedOut.Delete(volumeID)
+}
+
+// prune forgets the state of volumes, other than the given volume, which are
+// no longer present in the store, so it doesn't grow unbounded over the
+// lifetime of the driver.
+func (t *Timeout) prune(volumeID string) {
+	if t.volumes == nil {
+		return
+	}
+	volumeIDs, err := t.volumes.ListVolumes()
+	if err != nil {
+		return
+	}
+	present := sets.New(volumeIDs...).Insert(volumeID)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for id := range t.firstChecked {
+		if !present.Has(id) {
+			delete(t.firstChecked, id)
+			t.timedOut.Delete(id)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readinessgate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/cert-manager/csi-lib/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fakeclock "k8s.io/utils/clock/testing"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+func Test_NewTimeout(t *testing.T) {
+	tests := map[string]struct {
+		duration time.Duration
+		policy   TimeoutPolicy
+		expErr   bool
+	}{
+		"the issue policy should be accepted": {
+			duration: time.Minute,
+			policy:   TimeoutPolicyIssue,
+		},
+		"the fail policy should be accepted": {
+			duration: time.Minute,
+			policy:   TimeoutPolicyFail,
+		},
+		"an unknown policy should error": {
+			duration: time.Minute,
+			policy:   "retry",
+			expErr:   true,
+		},
+		"a zero timeout should error": {
+			policy: TimeoutPolicyFail,
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewTimeout(nil, fakeclock.NewFakeClock(time.Now()), test.duration, test.policy)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+		})
+	}
+}
+
+func Test_TimeoutReadyToRequest(t *testing.T) {
+	start := time.Now()
+
+	tests := map[string]struct {
+		policy TimeoutPolicy
+		issued bool
+		ready  bool
+		// publishedBefore, if set, is how long before start the publish
+		// time persisted in the volume's metadata is.
+		publishedBefore time.Duration
+		// notIssuable is whether the volume can't be issued without what it
+		// was waiting for.
+		notIssuable bool
+		// elapsed are the times since start at which the volume is checked.
+		elapsed []time.Duration
+
+		expReady              bool
+		expReason             string
+		expTimeouts           int
+		expCalls              int
+		expIssueWithoutPodIPs bool
+	}{
+		"a volume which is ready should be ready": {
+			policy:   TimeoutPolicyFail,
+			ready:    true,
+			elapsed:  []time.Duration{0},
+			expReady: true,
+			expCalls: 1,
+		},
+		"a volume not ready within the timeout should keep waiting": {
+			policy:    TimeoutPolicyFail,
+			elapsed:   []time.Duration{0, 4 * time.Minute},
+			expReason: "pod has no ipv6 address yet",
+			expCalls:  2,
+		},
+		"the fail policy should give up on the volume once timed out": {
+			policy:      TimeoutPolicyFail,
+			elapsed:     []time.Duration{0, 5 * time.Minute, 10 * time.Minute},
+			expReason:   "gave up waiting for the volume to be ready after 5m0s",
+			expTimeouts: 1,
+			expCalls:    2,
+		},
+		"the issue policy should issue without pod IPs once timed out": {
+			policy:                TimeoutPolicyIssue,
+			elapsed:               []time.Duration{0, 5 * time.Minute, 10 * time.Minute},
+			expReady:              true,
+			expTimeouts:           1,
+			expCalls:              2,
+			expIssueWithoutPodIPs: true,
+		},
+		"the issue policy should keep waiting for volumes which can't be issued once timed out": {
+			policy:                TimeoutPolicyIssue,
+			notIssuable:           true,
+			elapsed:               []time.Duration{0, 5 * time.Minute, 10 * time.Minute},
+			expReason:             "timed out waiting for the volume to be ready after 5m0s, but can't issue without: pod has no IPs yet",
+			expTimeouts:           1,
+			expCalls:              2,
+			expIssueWithoutPodIPs: true,
+		},
+		"the timeout should be measured from the persisted publish time": {
+			policy:          TimeoutPolicyFail,
+			publishedBefore: 4 * time.Minute,
+			elapsed:         []time.Duration{time.Minute},
+			expReason:       "gave up waiting for the volume to be ready after 5m0s",
+			expTimeouts:     1,
+			expCalls:        1,
+		},
+		"the timeout should be measured from when the volume was first checked": {
+			policy:    TimeoutPolicyFail,
+			elapsed:   []time.Duration{2 * time.Minute, 6 * time.Minute},
+			expReason: "pod has no ipv6 address yet",
+			expCalls:  2,
+		},
+		"a volume first checked more than the timeout ago should time out": {
+			policy:      TimeoutPolicyFail,
+			elapsed:     []time.Duration{2 * time.Minute, 7 * time.Minute},
+			expReason:   "gave up waiting for the volume to be ready after 5m0s",
+			expTimeouts: 1,
+			expCalls:    2,
+		},
+		"a volume which has been issued a certificate should never time out": {
+			policy:    TimeoutPolicyIssue,
+			issued:    true,
+			elapsed:   []time.Duration{0, time.Hour},
+			expReason: "pod has no ipv6 address yet",
+			expCalls:  2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta := metadata.Metadata{
+				VolumeID: "vol-id",
+				VolumeContext: map[string]string{
+					csiapi.K8sVolumeContextKeyPodName:      "my-pod",
+					csiapi.K8sVolumeContextKeyPodNamespace: "my-namespace",
+				},
+			}
+			if test.issued {
+				meta.NextIssuanceTime = &start
+			}
+			if test.publishedBefore > 0 {
+				meta.VolumeContext[publishTimeKey] = start.Add(-test.publishedBefore).Format(time.RFC3339Nano)
+			}
+
+			clock := fakeclock.NewFakeClock(start)
+			timeout, err := NewTimeout(nil, clock, 5*time.Minute, test.policy)
+			require.NoError(t, err)
+
+			var timeouts []string
+			timeout.OnTimeout = func(_ metadata.Metadata, reason string) {
+				timeouts = append(timeouts, reason)
+			}
+			timeout.Issuable = func(metadata.Metadata) (bool, string) {
+				if test.notIssuable {
+					return false, "pod has no IPs yet"
+				}
+				return true, ""
+			}
+			calls := 0
+			readyToRequest := timeout.ReadyToRequest(func(metadata.Metadata) (bool, string) {
+				calls++
+				if test.ready {
+					return true, ""
+				}
+				return false, "pod has no ipv6 address yet"
+			})
+
+			var ready bool
+			var reason string
+			for _, elapsed := range test.elapsed {
+				clock.SetTime(start.Add(elapsed))
+				ready, reason = readyToRequest(meta)
+			}
+
+			assert.Equal(t, test.expReady, ready)
+			assert.Equal(t, test.expReason, reason)
+			assert.Len(t, timeouts, test.expTimeouts)
+			for _, got := range timeouts {
+				assert.Equal(t, "pod has no ipv6 address yet", got)
+			}
+			assert.Equal(t, test.expCalls, calls)
+			assert.Equal(t, test.expIssueWithoutPodIPs, timeout.IssueWithoutPodIPs(meta))
+		})
+	}
+}
+
+func Test_TimeoutPrune(t *testing.T) {
+	store := storage.NewMemoryFS()
+	clock := fakeclock.NewFakeClock(time.Now())
+	timeout, err := NewTimeout(store, clock, 5*time.Minute, TimeoutPolicyIssue)
+	require.NoError(t, err)
+
+	notReady := timeout.ReadyToRequest(func(metadata.Metadata) (bool, string) {
+		return false, "not ready"
+	})
+
+	_, err = store.RegisterMetadata(metadata.Metadata{VolumeID: "vol-1"})
+	require.NoError(t, err)
+	notReady(metadata.Metadata{VolumeID: "vol-1"})
+	clock.Step(5 * time.Minute)
+	notReady(metadata.Metadata{VolumeID: "vol-1"})
+	assert.True(t, timeout.IssueWithoutPodIPs(metadata.Metadata{VolumeID: "vol-1"}))
+
+	// Checking a new volume should forget the removed volume.
+	require.NoError(t, store.RemoveVolume("vol-1"))
+	notReady(metadata.Metadata{VolumeID: "vol-2"})
+	assert.False(t, timeout.IssueWithoutPodIPs(metadata.Metadata{VolumeID: "vol-1"}))
+}
+
+func Test_TimeoutPersistsPublishTime(t *testing.T) {
+	store := storage.NewMemoryFS()
+	start := time.Now()
+	clock := fakeclock.NewFakeClock(start)
+	timeout, err := NewTimeout(store, clock, 5*time.Minute, TimeoutPolicyFail)
+	require.NoError(t, err)
+
+	notReady := func(metadata.Metadata) (bool, string) {
+		return false, "not ready"
+	}
+
+	_, err = store.RegisterMetadata(metadata.Metadata{VolumeID: "vol-1"})
+	require.NoError(t, err)
+	meta, err := store.ReadMetadata("vol-1")
+	require.NoError(t, err)
+	ready, _ := timeout.ReadyToRequest(notReady)(meta)
+	assert.False(t, ready)
+
+	meta, err = store.ReadMetadata("vol-1")
+	require.NoError(t, err)
+	assert.Equal(t, start.Format(time.RFC3339Nano), meta.VolumeContext[publishTimeKey])
+
+	// A restarted driver should time the volume out from the persisted
+	// publish time.
+	clock.Step(5 * time.Minute)
+	restarted, err := NewTimeout(store, clock, 5*time.Minute, TimeoutPolicyFail)
+	require.NoError(t, err)
+	_, reason := restarted.ReadyToRequest(notReady)(meta)
+	assert.Equal(t, "gave up waiting for the volume to be ready after 5m0s", reason)
+}
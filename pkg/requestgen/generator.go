@@ -24,6 +24,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -33,21 +34,75 @@ import (
 	cmpki "github.com/cert-manager/cert-manager/pkg/util/pki"
 	"github.com/cert-manager/csi-lib/manager"
 	"github.com/cert-manager/csi-lib/metadata"
+	corev1 "k8s.io/api/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 
 	"github.com/cert-manager/csi-driver/pkg/apis"
 	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
 	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+	"github.com/cert-manager/csi-driver/pkg/policy"
 )
 
+// Generator builds csi-lib CertificateRequestBundles for volumes.
+type Generator struct {
+	// Policy, if set, is checked against every request before it is
+	// returned, so that requests which violate it are never created.
+	Policy *policy.Engine
+
+	// PodLister is used to read attributes from the pod owning the volume,
+	// e.g. for csi.cert-manager.io/ip-sans-from-pod. It is expected to be
+	// backed by the driver's node-scoped pod informer.
+	PodLister corev1listers.PodLister
+
+	// NodeName is the value of the NODE_NAME expansion variable.
+	NodeName string
+
+	// ClusterDefaults are the cluster-wide default attributes, layered under
+	// the attributes of each volume.
+	ClusterDefaults map[string]string
+
+	// Validation configures the validation of each volume's attributes.
+	Validation validation.Options
+
+	// IssueWithoutPodIPs, if set, reports whether the volume's request
+	// should be built without the csi.cert-manager.io/ip-sans-from-pod IPs
+	// which the pod has not yet been assigned, e.g. because the volume timed
+	// out waiting for them, rather than failing.
+	IssueWithoutPodIPs func(meta metadata.Metadata) bool
+}
+
 // RequestForMetadata returns a csi-lib CertificateRequestBundle built using
-// the volume attributed contained within the passed metadata.
+// the volume attributed contained within the passed metadata, without
+// checking any issuance policy.
 func RequestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBundle, error) {
-	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext)
+	return new(Generator).RequestForMetadata(meta)
+}
+
+// RequestForMetadata returns a csi-lib CertificateRequestBundle built using
+// the volume attributed contained within the passed metadata. Returns an
+// error if the request violates the Generator's issuance policy.
+func (g *Generator) RequestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBundle, error) {
+	bundle, err := g.requestForMetadata(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.Policy != nil {
+		if err := g.Policy.Check(bundle); err != nil {
+			return nil, err
+		}
+	}
+
+	return bundle, nil
+}
+
+func (g *Generator) requestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBundle, error) {
+	attrs, err := defaults.SetDefaultAttributes(meta.VolumeContext, g.ClusterDefaults)
 	if err != nil {
 		return nil, err
 	}
-	if err := validation.ValidateAttributes(attrs); err != nil {
+	if err := validation.ValidateAttributes(attrs, g.Validation); err != nil {
 		return nil, err.ToAggregate()
 	}
 
@@ -61,7 +116,7 @@ func RequestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBund
 
 	var request = &x509.CertificateRequest{}
 	if lSubjStr, ok := attrs[csiapi.LiteralSubjectKey]; ok && len(lSubjStr) > 0 {
-		lSubjStr, err = expand(meta, lSubjStr)
+		lSubjStr, err = g.expand(meta, lSubjStr)
 		if err != nil {
 			return nil, fmt.Errorf("%q: %w", csiapi.LiteralSubjectKey, err)
 		}
@@ -75,7 +130,7 @@ func RequestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBund
 		}
 	} else {
 		request.Subject = pkix.Name{}
-		request.Subject.CommonName, err = expand(meta, attrs[csiapi.CommonNameKey])
+		request.Subject.CommonName, err = g.expand(meta, attrs[csiapi.CommonNameKey])
 		if err != nil {
 			return nil, fmt.Errorf("%q: %w", csiapi.CommonNameKey, err)
 		}
@@ -92,7 +147,7 @@ func RequestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBund
 			&request.Subject.PostalCode:         csiapi.PostalCodesKey,
 		} {
 			if len(attrs[v]) > 0 {
-				var e, err = expand(meta, attrs[v])
+				var e, err = g.expand(meta, attrs[v])
 				if err != nil {
 					return nil, fmt.Errorf("%q: %w", v, err)
 				}
@@ -103,15 +158,30 @@ func RequestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBund
 			}
 		}
 	}
-	request.DNSNames, err = parseDNSNames(meta, attrs[csiapi.DNSNamesKey])
+	request.DNSNames, err = g.parseDNSNames(meta, attrs[csiapi.DNSNamesKey])
 	if err != nil {
 		return nil, fmt.Errorf("%q: %w", csiapi.DNSNamesKey, err)
 	}
-	request.IPAddresses, err = parseIPAddresses(attrs[csiapi.IPSANsKey])
+	ipCSV, err := g.expand(meta, attrs[csiapi.IPSANsKey])
 	if err != nil {
 		return nil, fmt.Errorf("%q: %w", csiapi.IPSANsKey, err)
 	}
-	request.URIs, err = parseURIs(meta, attrs[csiapi.URISANsKey])
+	request.IPAddresses, err = parseIPAddresses(ipCSV)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", csiapi.IPSANsKey, err)
+	}
+	if sources := attrs[csiapi.IPSANsFromPodKey]; len(sources) > 0 {
+		podIPs, err := g.podIPSANs(meta, sources)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", csiapi.IPSANsFromPodKey, err)
+		}
+		for _, ip := range podIPs {
+			if !slices.ContainsFunc(request.IPAddresses, ip.Equal) {
+				request.IPAddresses = append(request.IPAddresses, ip)
+			}
+		}
+	}
+	request.URIs, err = g.parseURIs(meta, attrs[csiapi.URISANsKey])
 	if err != nil {
 		return nil, fmt.Errorf("%q: %w", csiapi.URISANsKey, err)
 	}
@@ -146,11 +216,11 @@ func RequestForMetadata(meta metadata.Metadata) (*manager.CertificateRequestBund
 
 // parseDNSNames parses a csi.cert-manager.io/dns-names value, and returns the
 // set of DNS names to be requested. Executes metadata expand on string.
-func parseDNSNames(meta metadata.Metadata, dnsNames string) ([]string, error) {
+func (g *Generator) parseDNSNames(meta metadata.Metadata, dnsNames string) ([]string, error) {
 	if len(dnsNames) == 0 {
 		return nil, nil
 	}
-	dns, err := expand(meta, dnsNames)
+	dns, err := g.expand(meta, dnsNames)
 	if err != nil {
 		return nil, err
 	}
@@ -184,12 +254,12 @@ func parseIPAddresses(ipCSV string) ([]net.IP, error) {
 
 // parseIPAddresses parses a csi.cert-manager.io/uri-sans value, and returns
 // the set of URI SANs to be requested. Executes metadata expand on string.
-func parseURIs(meta metadata.Metadata, uriCSV string) ([]*url.URL, error) {
+func (g *Generator) parseURIs(meta metadata.Metadata, uriCSV string) ([]*url.URL, error) {
 	if len(uriCSV) == 0 {
 		return nil, nil
 	}
 
-	csv, err := expand(meta, uriCSV)
+	csv, err := g.expand(meta, uriCSV)
 	if err != nil {
 		return nil, err
 	}
@@ -229,20 +299,39 @@ func keyUsagesFromAttributes(usagesCSV string) []cmapi.KeyUsage {
 }
 
 // expand executes os.Expand on the given csv with volume context variables
-// provided by the metadata.
-func expand(meta metadata.Metadata, csv string) (string, error) {
+// provided by the metadata. Variables read from the pod are only looked up
+// if referenced.
+func (g *Generator) expand(meta metadata.Metadata, csv string) (string, error) {
 	vars := map[string]string{
 		"POD_NAME":             meta.VolumeContext[csiapi.K8sVolumeContextKeyPodName],
 		"POD_NAMESPACE":        meta.VolumeContext[csiapi.K8sVolumeContextKeyPodNamespace],
 		"POD_UID":              meta.VolumeContext[csiapi.K8sVolumeContextKeyPodUID],
 		"SERVICE_ACCOUNT_NAME": meta.VolumeContext[csiapi.K8sVolumeContextKeyServiceAccountName],
+		"NODE_NAME":            g.NodeName,
 	}
 
+	var pod *corev1.Pod
+	var podErr error
 	var errs []string
 	exp := os.Expand(csv, func(s string) string {
-		v, ok := vars[s]
-		if !ok {
+		if v, ok := vars[s]; ok {
+			return v
+		}
+		if !isPodVariable(s) {
 			errs = append(errs, fmt.Sprintf("undefined variable %q", s))
+			return ""
+		}
+
+		if pod == nil && podErr == nil {
+			pod, podErr = g.podForMetadata(meta)
+		}
+		if podErr != nil {
+			return ""
+		}
+
+		v, err := podVariable(pod, s)
+		if err != nil && podErr == nil {
+			podErr = err
 		}
 		return v
 	})
@@ -250,9 +339,13 @@ func expand(meta metadata.Metadata, csv string) (string, error) {
 	if len(errs) > 0 {
 		return "", fmt.Errorf("%v, known variables: %v",
 			strings.Join(errs, ", "),
-			[]string{"POD_NAME", "POD_NAMESPACE", "POD_UID", "SERVICE_ACCOUNT_NAME"},
+			[]string{"POD_NAME", "POD_NAMESPACE", "POD_UID", "SERVICE_ACCOUNT_NAME", "NODE_NAME",
+				"POD_IP", "POD_IPS", podLabelVariablePrefix + "<key>", podAnnotationVariablePrefix + "<key>"},
 		)
 	}
+	if podErr != nil {
+		return "", podErr
+	}
 
 	return exp, nil
 }
@@ -35,6 +35,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/policy"
 )
 
 func Test_RequestForMetadata(t *testing.T) {
@@ -257,7 +258,7 @@ func Test_parseDNSNames(t *testing.T) {
 		"if references a variable that doesn't exist, error": {
 			csv:         `$POD_NAME-my-dns-${POD_NAMESPACE}-$POD_UID-$Foo`,
 			expDNSNames: nil,
-			expErr:      errors.New(`undefined variable "Foo", known variables: [POD_NAME POD_NAMESPACE POD_UID SERVICE_ACCOUNT_NAME]`),
+			expErr:      errors.New(`undefined variable "Foo", known variables: [POD_NAME POD_NAMESPACE POD_UID SERVICE_ACCOUNT_NAME NODE_NAME POD_IP POD_IPS POD_LABEL_<key> POD_ANNOTATION_<key>]`),
 		},
 		"a csv containing multiple entries which uses should be substituted correctly": {
 			csv:         `$POD_NAME-my-dns-${POD_NAMESPACE}-$POD_UID,$POD_NAME,$POD_NAME.$POD_NAMESPACE,$POD_NAME.$POD_NAMESPACE.svc,$POD_UID`,
@@ -268,7 +269,7 @@ func Test_parseDNSNames(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			got, err := parseDNSNames(baseMetadata(), test.csv)
+			got, err := new(Generator).parseDNSNames(baseMetadata(), test.csv)
 			assert.Equal(t, test.expErr, err)
 			assert.ElementsMatch(t, test.expDNSNames, got)
 		})
@@ -331,7 +332,7 @@ func Test_URIs(t *testing.T) {
 		"if variables references a variable that doesn't exist, error": {
 			csv:     `$POD_NAME-my-dns-${POD_NAMESPACE}-$POD_UID-${Foo}`,
 			expURIs: nil,
-			expErr:  errors.New(`undefined variable "Foo", known variables: [POD_NAME POD_NAMESPACE POD_UID SERVICE_ACCOUNT_NAME]`),
+			expErr:  errors.New(`undefined variable "Foo", known variables: [POD_NAME POD_NAMESPACE POD_UID SERVICE_ACCOUNT_NAME NODE_NAME POD_IP POD_IPS POD_LABEL_<key> POD_ANNOTATION_<key>]`),
 		},
 		"a csv containing multiple entries which uses variables should be substituted correctly": {
 			csv: `spiffe://$POD_NAME-my-dns-${POD_NAMESPACE}-$POD_UID,spiffe://$POD_NAME,file://${POD_NAME}.$POD_NAMESPACE,foo://$POD_NAME.$POD_NAMESPACE.svc,spiffe://$POD_UID`,
@@ -350,7 +351,7 @@ func Test_URIs(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			got, err := parseURIs(baseMetadata(), test.csv)
+			got, err := new(Generator).parseURIs(baseMetadata(), test.csv)
 			assert.Equal(t, test.expErr, err)
 			var expURIs []*url.URL
 			if test.expURIs != nil {
@@ -382,19 +383,43 @@ func Test_expand(t *testing.T) {
 		"if reference a variable that does not exist, expect error": {
 			input:     "foo-${POD_NAME}-,,$POD_NAMESPACE,${POD_UID}.$Foo${Bar}",
 			expOutput: "",
-			expErr:    errors.New(`undefined variable "Foo", undefined variable "Bar", known variables: [POD_NAME POD_NAMESPACE POD_UID SERVICE_ACCOUNT_NAME]`),
+			expErr:    errors.New(`undefined variable "Foo", undefined variable "Bar", known variables: [POD_NAME POD_NAMESPACE POD_UID SERVICE_ACCOUNT_NAME NODE_NAME POD_IP POD_IPS POD_LABEL_<key> POD_ANNOTATION_<key>]`),
 		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			output, err := expand(baseMetadata(), test.input)
+			output, err := new(Generator).expand(baseMetadata(), test.input)
 			assert.Equal(t, test.expErr, err)
 			assert.Equal(t, test.expOutput, output)
 		})
 	}
 }
 
+func Test_GeneratorRequestForMetadataPolicy(t *testing.T) {
+	t.Parallel()
+
+	engine := policy.NewEngine()
+	assert.NoError(t, engine.Load([]byte(`policies: [{namespaces: ["my-namespace"], allowedDNSNames: ['.*\.example\.com']}]`)))
+	generator := Generator{Policy: engine}
+
+	meta := baseMetadata()
+	meta.VolumeContext[csiapi.IssuerNameKey] = "my-issuer"
+	meta.VolumeContext[csiapi.DNSNamesKey] = "${POD_NAME}.example.com"
+	bundle, err := generator.RequestForMetadata(meta)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"my-pod-name.example.com"}, bundle.Request.DNSNames)
+
+	meta.VolumeContext[csiapi.DNSNamesKey] = "${POD_NAME}.example.org"
+	bundle, err = generator.RequestForMetadata(meta)
+	assert.EqualError(t, err, `request denied by issuance policy for namespace "my-namespace": DNS name "my-pod-name.example.org" is not allowed`)
+	assert.Nil(t, bundle)
+
+	// The package level function doesn't check any policy.
+	_, err = RequestForMetadata(meta)
+	assert.NoError(t, err)
+}
+
 func baseMetadata() metadata.Metadata {
 	return metadata.Metadata{
 		VolumeContext: map[string]string{
@@ -0,0 +1,240 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cert-manager/csi-lib/metadata"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+// networkStatusAnnotation is the annotation Multus writes the status of each
+// of the pod's network attachments to.
+const networkStatusAnnotation = "k8s.v1.cni.cncf.io/network-status"
+
+const (
+	// podLabelVariablePrefix is the prefix of expansion variables which are
+	// replaced with the value of the pod label named by the rest of the
+	// variable, e.g. ${POD_LABEL_app}.
+	podLabelVariablePrefix = "POD_LABEL_"
+
+	// podAnnotationVariablePrefix is the prefix of expansion variables which
+	// are replaced with the value of the pod annotation named by the rest of
+	// the variable.
+	podAnnotationVariablePrefix = "POD_ANNOTATION_"
+)
+
+// networkStatus is a single entry of the Multus network-status annotation.
+type networkStatus struct {
+	Name string   `json:"name"`
+	IPs  []string `json:"ips"`
+}
+
+// notReadyError is returned when an attribute read from the pod can't be
+// resolved yet, e.g. because the pod has not yet been assigned IPs.
+type notReadyError struct {
+	reason string
+}
+
+func (e *notReadyError) Error() string {
+	return e.reason
+}
+
+func notReadyf(format string, args ...any) error {
+	return &notReadyError{reason: fmt.Sprintf(format, args...)}
+}
+
+// ReadyToRequest is a manager.ReadyToRequestFunc which defers issuance until
+// every attribute read from the pod can be resolved, e.g. until the pod has
+// been assigned the IPs requested by csi.cert-manager.io/ip-sans-from-pod.
+// Labels and annotations referenced by POD_LABEL_<key> and
+// POD_ANNOTATION_<key> variables aren't waited for, so request generation
+// fails if the pod doesn't have them. Volumes which don't read from the pod
+// are always ready. Since pods have no IPs until
+// their volumes are mounted, volumes using pod IPs require the driver to be
+// run with --continue-on-not-ready.
+func (g *Generator) ReadyToRequest(meta metadata.Metadata) (bool, string) {
+	_, err := g.requestForMetadata(meta)
+
+	var notReady *notReadyError
+	if errors.As(err, &notReady) {
+		return false, err.Error()
+	}
+
+	// Let request generation report any other error.
+	return true, ""
+}
+
+// podForMetadata returns the pod owning the volume from the pod lister.
+func (g *Generator) podForMetadata(meta metadata.Metadata) (*corev1.Pod, error) {
+	if g.PodLister == nil {
+		return nil, notReadyf("the pod is not available to read attributes from")
+	}
+
+	podName := meta.VolumeContext[csiapi.K8sVolumeContextKeyPodName]
+	podNamespace := meta.VolumeContext[csiapi.K8sVolumeContextKeyPodNamespace]
+	if podName == "" || podNamespace == "" {
+		return nil, notReadyf("pod name or namespace not present in volume context")
+	}
+
+	pod, err := g.PodLister.Pods(podNamespace).Get(podName)
+	if apierrors.IsNotFound(err) {
+		return nil, notReadyf("pod %s/%s not yet observed by informer", podNamespace, podName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod %s/%s from informer: %w", podNamespace, podName, err)
+	}
+
+	return pod, nil
+}
+
+// podIPSANs returns the IPs of the pod owning the volume for the given
+// csi.cert-manager.io/ip-sans-from-pod sources. If the volume is to be issued
+// without the IPs the pod has not yet been assigned, none are returned rather
+// than an error.
+func (g *Generator) podIPSANs(meta metadata.Metadata, sources string) ([]net.IP, error) {
+	pod, err := g.podForMetadata(meta)
+	if err == nil {
+		var ips []net.IP
+		if ips, err = ipSANsFromPod(pod, sources); err == nil {
+			return ips, nil
+		}
+	}
+
+	var notReady *notReadyError
+	if errors.As(err, &notReady) && g.IssueWithoutPodIPs != nil && g.IssueWithoutPodIPs(meta) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// ipSANsFromPod returns the IPs of the pod for the given
+// csi.cert-manager.io/ip-sans-from-pod sources. Returns an error if any source
+// has no IPs yet.
+func ipSANsFromPod(pod *corev1.Pod, sources string) ([]net.IP, error) {
+	var networks []networkStatus
+	var ips []net.IP
+	for _, source := range splitList(sources) {
+		if source == "pod-ips" {
+			if len(pod.Status.PodIPs) == 0 {
+				return nil, notReadyf("pod has no IPs yet")
+			}
+			for _, podIP := range pod.Status.PodIPs {
+				ips = append(ips, net.ParseIP(podIP.IP))
+			}
+			continue
+		}
+
+		name, _ := strings.CutPrefix(source, "network:")
+		if networks == nil {
+			var err error
+			if networks, err = parseNetworkStatus(pod); err != nil {
+				return nil, err
+			}
+		}
+
+		network, ok := findNetwork(networks, pod.Namespace, name)
+		if !ok || len(network.IPs) == 0 {
+			return nil, notReadyf("pod has no IPs on network %q yet", name)
+		}
+		for _, ip := range network.IPs {
+			parsed := net.ParseIP(ip)
+			if parsed == nil {
+				return nil, fmt.Errorf("network %q has invalid IP %q", name, ip)
+			}
+			ips = append(ips, parsed)
+		}
+	}
+
+	return ips, nil
+}
+
+// parseNetworkStatus parses the Multus network-status annotation of the pod.
+func parseNetworkStatus(pod *corev1.Pod) ([]networkStatus, error) {
+	annotation, ok := pod.Annotations[networkStatusAnnotation]
+	if !ok || annotation == "" {
+		return nil, notReadyf("pod does not yet have annotation %q", networkStatusAnnotation)
+	}
+
+	networks := []networkStatus{}
+	if err := json.Unmarshal([]byte(annotation), &networks); err != nil {
+		return nil, fmt.Errorf("failed to parse pod annotation %q: %w", networkStatusAnnotation, err)
+	}
+
+	return networks, nil
+}
+
+// findNetwork returns the network with the given name. Multus names network
+// attachments "<namespace>/<name>", so a name without a namespace also
+// matches an attachment in the pod's namespace.
+func findNetwork(networks []networkStatus, namespace, name string) (networkStatus, bool) {
+	for _, network := range networks {
+		if network.Name == name || (!strings.Contains(name, "/") && network.Name == namespace+"/"+name) {
+			return network, true
+		}
+	}
+	return networkStatus{}, false
+}
+
+// isPodVariable returns true if the expansion variable is read from the pod.
+func isPodVariable(name string) bool {
+	return name == "POD_IP" || name == "POD_IPS" ||
+		(strings.HasPrefix(name, podLabelVariablePrefix) && len(name) > len(podLabelVariablePrefix)) ||
+		(strings.HasPrefix(name, podAnnotationVariablePrefix) && len(name) > len(podAnnotationVariablePrefix))
+}
+
+// podVariable returns the value of the given pod expansion variable.
+func podVariable(pod *corev1.Pod, name string) (string, error) {
+	switch {
+	case name == "POD_IP" || name == "POD_IPS":
+		if len(pod.Status.PodIPs) == 0 {
+			return "", notReadyf("pod has no IPs yet")
+		}
+		if name == "POD_IP" {
+			return pod.Status.PodIPs[0].IP, nil
+		}
+		ips := make([]string, 0, len(pod.Status.PodIPs))
+		for _, podIP := range pod.Status.PodIPs {
+			ips = append(ips, podIP.IP)
+		}
+		return strings.Join(ips, ","), nil
+
+	case strings.HasPrefix(name, podLabelVariablePrefix):
+		key := strings.TrimPrefix(name, podLabelVariablePrefix)
+		value, ok := pod.Labels[key]
+		if !ok {
+			return "", fmt.Errorf("pod has no label %q for the %s variable", key, name)
+		}
+		return value, nil
+
+	default:
+		key := strings.TrimPrefix(name, podAnnotationVariablePrefix)
+		value, ok := pod.Annotations[key]
+		if !ok {
+			return "", fmt.Errorf("pod has no annotation %q for the %s variable", key, name)
+		}
+		return value, nil
+	}
+}
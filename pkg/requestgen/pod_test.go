@@ -0,0 +1,359 @@
+/*
+Copyright 2021 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestgen
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cert-manager/csi-lib/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+)
+
+// newPodLister returns a PodLister backed by an in-memory indexer, optionally
+// pre-populated with the given pod.
+func newPodLister(t *testing.T, pod *corev1.Pod) corev1listers.PodLister {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+	if pod != nil {
+		require.NoError(t, indexer.Add(pod))
+	}
+	return corev1listers.NewPodLister(indexer)
+}
+
+func Test_ipSANsFromPod(t *testing.T) {
+	t.Parallel()
+
+	const networkStatus = `[
+  {"name": "cbr0", "interface": "eth0", "ips": ["10.0.0.1"], "default": true},
+  {"name": "my-namespace/macvlan", "interface": "net1", "ips": ["192.168.1.10", "fd00::10"]},
+  {"name": "other/empty", "interface": "net2"}
+]`
+
+	tests := map[string]struct {
+		pod     *corev1.Pod
+		sources string
+
+		expIPs []net.IP
+		expErr string
+	}{
+		"pod-ips should return every pod IP": {
+			pod: &corev1.Pod{Status: corev1.PodStatus{
+				PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+			}},
+			sources: "pod-ips",
+			expIPs:  []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")},
+		},
+		"pod-ips with no IPs should error": {
+			pod:     &corev1.Pod{},
+			sources: "pod-ips",
+			expErr:  "pod has no IPs yet",
+		},
+		"a network should match without its namespace": {
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "my-namespace",
+				Annotations: map[string]string{networkStatusAnnotation: networkStatus},
+			}},
+			sources: "network:macvlan",
+			expIPs:  []net.IP{net.ParseIP("192.168.1.10"), net.ParseIP("fd00::10")},
+		},
+		"a network should match with its namespace": {
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "other-namespace",
+				Annotations: map[string]string{networkStatusAnnotation: networkStatus},
+			}},
+			sources: "network:my-namespace/macvlan",
+			expIPs:  []net.IP{net.ParseIP("192.168.1.10"), net.ParseIP("fd00::10")},
+		},
+		"a network in another namespace should not match without its namespace": {
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "other-namespace",
+				Annotations: map[string]string{networkStatusAnnotation: networkStatus},
+			}},
+			sources: "network:macvlan",
+			expErr:  `pod has no IPs on network "macvlan" yet`,
+		},
+		"a network with no IPs should error": {
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "my-namespace",
+				Annotations: map[string]string{networkStatusAnnotation: networkStatus},
+			}},
+			sources: "network:other/empty",
+			expErr:  `pod has no IPs on network "other/empty" yet`,
+		},
+		"a missing network-status annotation should error": {
+			pod:     &corev1.Pod{},
+			sources: "network:macvlan",
+			expErr:  `pod does not yet have annotation "k8s.v1.cni.cncf.io/network-status"`,
+		},
+		"an invalid network-status annotation should error": {
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{networkStatusAnnotation: "{"},
+			}},
+			sources: "network:macvlan",
+			expErr:  `failed to parse pod annotation "k8s.v1.cni.cncf.io/network-status": unexpected end of JSON input`,
+		},
+		"multiple sources should be combined": {
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "my-namespace",
+					Annotations: map[string]string{networkStatusAnnotation: networkStatus},
+				},
+				Status: corev1.PodStatus{PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}}},
+			},
+			sources: "pod-ips, network:macvlan",
+			expIPs:  []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("192.168.1.10"), net.ParseIP("fd00::10")},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ips, err := ipSANsFromPod(test.pod, test.sources)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expIPs, ips)
+		})
+	}
+}
+
+func Test_GeneratorIPSANsFromPod(t *testing.T) {
+	t.Parallel()
+
+	// Attributes may only be read from the pod when the driver continues on
+	// not ready.
+	opts := validation.Options{PodAttributesAllowed: true}
+
+	withoutAttribute := baseMetadata()
+	withoutAttribute.VolumeContext[csiapi.IssuerNameKey] = "my-issuer"
+
+	meta := baseMetadata()
+	meta.VolumeContext[csiapi.IssuerNameKey] = "my-issuer"
+	meta.VolumeContext[csiapi.IPSANsKey] = "10.0.0.1"
+	meta.VolumeContext[csiapi.IPSANsFromPodKey] = "pod-ips"
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-pod-name", Namespace: "my-namespace"},
+		Status: corev1.PodStatus{
+			PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+		},
+	}
+
+	tests := map[string]struct {
+		generator *Generator
+		meta      metadata.Metadata
+
+		expReady  bool
+		expReason string
+		expIPs    []net.IP
+		expErr    string
+	}{
+		"a volume without the attribute should always be ready": {
+			generator: &Generator{Validation: opts},
+			meta:      withoutAttribute,
+			expReady:  true,
+		},
+		"no pod lister should not be ready": {
+			generator: &Generator{Validation: opts},
+			meta:      meta,
+			expReady:  false,
+			expReason: `"csi.cert-manager.io/ip-sans-from-pod": the pod is not available to read attributes from`,
+			expErr:    `"csi.cert-manager.io/ip-sans-from-pod": the pod is not available to read attributes from`,
+		},
+		"a pod not yet observed should not be ready": {
+			generator: &Generator{PodLister: newPodLister(t, nil), Validation: opts},
+			meta:      meta,
+			expReady:  false,
+			expReason: `"csi.cert-manager.io/ip-sans-from-pod": pod my-namespace/my-pod-name not yet observed by informer`,
+			expErr:    `"csi.cert-manager.io/ip-sans-from-pod": pod my-namespace/my-pod-name not yet observed by informer`,
+		},
+		"a pod without IPs should not be ready": {
+			generator: &Generator{
+				PodLister: newPodLister(t, &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-pod-name", Namespace: "my-namespace"},
+				}),
+				Validation: opts,
+			},
+			meta:      meta,
+			expReady:  false,
+			expReason: `"csi.cert-manager.io/ip-sans-from-pod": pod has no IPs yet`,
+			expErr:    `"csi.cert-manager.io/ip-sans-from-pod": pod has no IPs yet`,
+		},
+		"pod IPs should be added to the request without duplicates": {
+			generator: &Generator{PodLister: newPodLister(t, pod), Validation: opts},
+			meta:      meta,
+			expReady:  true,
+			expIPs:    []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")},
+		},
+		"a pod without IPs should be issued without them once the volume is issued without pod IPs": {
+			generator: &Generator{
+				PodLister: newPodLister(t, &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-pod-name", Namespace: "my-namespace"},
+				}),
+				IssueWithoutPodIPs: func(metadata.Metadata) bool { return true },
+				Validation:         opts,
+			},
+			meta:     meta,
+			expReady: true,
+			expIPs:   []net.IP{net.ParseIP("10.0.0.1")},
+		},
+		"a pod not yet observed should be issued without IPs once the volume is issued without pod IPs": {
+			generator: &Generator{
+				PodLister:          newPodLister(t, nil),
+				IssueWithoutPodIPs: func(metadata.Metadata) bool { return true },
+				Validation:         opts,
+			},
+			meta:     meta,
+			expReady: true,
+			expIPs:   []net.IP{net.ParseIP("10.0.0.1")},
+		},
+		"pod IPs should still be added once the volume is issued without pod IPs": {
+			generator: &Generator{
+				PodLister:          newPodLister(t, pod),
+				IssueWithoutPodIPs: func(metadata.Metadata) bool { return true },
+				Validation:         opts,
+			},
+			meta:     meta,
+			expReady: true,
+			expIPs:   []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ready, reason := test.generator.ReadyToRequest(test.meta)
+			assert.Equal(t, test.expReady, ready)
+			assert.Equal(t, test.expReason, reason)
+
+			bundle, err := test.generator.RequestForMetadata(test.meta)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, len(test.expIPs), len(bundle.Request.IPAddresses))
+			for i, ip := range test.expIPs {
+				assert.True(t, ip.Equal(bundle.Request.IPAddresses[i]), "expected %s, got %s", ip, bundle.Request.IPAddresses[i])
+			}
+		})
+	}
+}
+
+func Test_GeneratorPodVariables(t *testing.T) {
+	t.Parallel()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-pod-name",
+			Namespace:   "my-namespace",
+			Labels:      map[string]string{"app": "my-app", "app.kubernetes.io/name": "my-name"},
+			Annotations: map[string]string{"example.com/role": "frontend"},
+		},
+		Status: corev1.PodStatus{
+			PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+		},
+	}
+	// Attributes may only be read from the pod when the driver continues on
+	// not ready.
+	opts := validation.Options{PodAttributesAllowed: true}
+	generator := &Generator{PodLister: newPodLister(t, pod), NodeName: "my-node", Validation: opts}
+
+	tests := map[string]struct {
+		generator *Generator
+		input     string
+
+		expOutput string
+		expErr    string
+		expReady  bool
+	}{
+		"node name should be expanded without the pod": {
+			generator: &Generator{NodeName: "my-node", Validation: opts},
+			input:     "${POD_NAME}.${NODE_NAME}",
+			expOutput: "my-pod-name.my-node",
+			expReady:  true,
+		},
+		"pod IPs should be expanded": {
+			generator: generator,
+			input:     "$POD_IP|$POD_IPS",
+			expOutput: "10.0.0.1|10.0.0.1,fd00::1",
+			expReady:  true,
+		},
+		"pod labels and annotations should be expanded": {
+			generator: generator,
+			input:     "spiffe://td/ns/$POD_NAMESPACE/app/$POD_LABEL_app/${POD_LABEL_app.kubernetes.io/name}/${POD_ANNOTATION_example.com/role}",
+			expOutput: "spiffe://td/ns/my-namespace/app/my-app/my-name/frontend",
+			expReady:  true,
+		},
+		"a missing label should error rather than wait": {
+			generator: generator,
+			input:     "$POD_LABEL_version",
+			expErr:    `pod has no label "version" for the POD_LABEL_version variable`,
+			expReady:  true,
+		},
+		"a missing annotation should error rather than wait": {
+			generator: generator,
+			input:     "${POD_ANNOTATION_example.com/missing}",
+			expErr:    `pod has no annotation "example.com/missing" for the POD_ANNOTATION_example.com/missing variable`,
+			expReady:  true,
+		},
+		"a pod not yet observed should not be ready": {
+			generator: &Generator{PodLister: newPodLister(t, nil), Validation: opts},
+			input:     "$POD_IP",
+			expErr:    "pod my-namespace/my-pod-name not yet observed by informer",
+			expReady:  false,
+		},
+		"an empty label prefix should be an undefined variable": {
+			generator: generator,
+			input:     "$POD_LABEL_",
+			expErr:    `undefined variable "POD_LABEL_", known variables: [POD_NAME POD_NAMESPACE POD_UID SERVICE_ACCOUNT_NAME NODE_NAME POD_IP POD_IPS POD_LABEL_<key> POD_ANNOTATION_<key>]`,
+			expReady:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			output, err := test.generator.expand(baseMetadata(), test.input)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expOutput, output)
+
+			meta := baseMetadata()
+			meta.VolumeContext[csiapi.IssuerNameKey] = "my-issuer"
+			meta.VolumeContext[csiapi.CommonNameKey] = test.input
+			ready, _ := test.generator.ReadyToRequest(meta)
+			assert.Equal(t, test.expReady, ready)
+		})
+	}
+}
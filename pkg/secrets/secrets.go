@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package secrets reads values, such as passwords, from the Secrets
+// referenced by volume attributes.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+
+	csiapi "github.com/cert-manager/csi-driver/pkg/apis/v1alpha1"
+)
+
+// timeout is the maximum time to wait for a Secret to be read.
+const timeout = 10 * time.Second
+
+// serviceAccountTokensKey is the volume context key holding the service
+// account tokens requested by the CSIDriver's tokenRequests, passed from the
+// kubelet during PublishVolume calls.
+const serviceAccountTokensKey = "csi.storage.k8s.io/serviceAccount.tokens" // #nosec G101: False positive, gosec thinks this is a credential.
+
+// ClientForAttributesFunc returns the client used to read the Secrets
+// referenced by the given volume attributes.
+type ClientForAttributesFunc func(attrs map[string]string) (corev1client.SecretsGetter, error)
+
+// Getter reads the Secrets referenced by volume attributes. Secrets are
+// always read from the namespace of the pod owning the volume, so that a pod
+// may only reference the Secrets in its own namespace.
+type Getter struct {
+	// Client is used to read Secrets, unless ClientForAttributes is set.
+	Client corev1client.SecretsGetter
+
+	// ClientForAttributes, if set, returns the client used to read the
+	// Secrets of each volume, e.g. authenticated as the pod's service
+	// account.
+	ClientForAttributes ClientForAttributesFunc
+}
+
+// ClientForAttributesTokenRequestEmptyAud returns a ClientForAttributesFunc
+// which reads Secrets authenticated as the pod's service account, using the
+// empty audience token given by the kubelet when the CSIDriver's
+// tokenRequests is set. Pods may then only reference the Secrets which their
+// service account may read.
+func ClientForAttributesTokenRequestEmptyAud(restConfig *rest.Config) ClientForAttributesFunc {
+	return func(attrs map[string]string) (corev1client.SecretsGetter, error) {
+		tokens := make(map[string]struct {
+			Token string `json:"token"`
+		})
+		if err := json.Unmarshal([]byte(attrs[serviceAccountTokensKey]), &tokens); err != nil {
+			return nil, fmt.Errorf("failed to parse service account tokens: %w", err)
+		}
+
+		token, ok := tokens[""]
+		if !ok {
+			return nil, errors.New("no service account token with the empty audience was given")
+		}
+
+		config := rest.AnonymousClientConfig(restConfig)
+		config.BearerToken = token.Token
+
+		return corev1client.NewForConfig(config)
+	}
+}
+
+// Password returns the password given directly by the passwordKey
+// attribute, or else read from the Secret named by the secretNameKey
+// attribute, at the data key given by the secretKeyKey attribute. It returns
+// nil if neither attribute is set. A nil Getter may be used when no Secrets
+// are referenced.
+func (g *Getter) Password(attrs map[string]string, passwordKey, secretNameKey, secretKeyKey string) ([]byte, error) {
+	if password, ok := attrs[passwordKey]; ok {
+		return []byte(password), nil
+	}
+
+	name, ok := attrs[secretNameKey]
+	if !ok {
+		return nil, nil
+	}
+
+	client, err := g.clientFor(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return value(client, attrs[csiapi.K8sVolumeContextKeyPodNamespace], name, attrs[secretKeyKey])
+}
+
+// clientFor returns the client used to read the Secrets referenced by the
+// given attributes.
+func (g *Getter) clientFor(attrs map[string]string) (corev1client.SecretsGetter, error) {
+	switch {
+	case g == nil:
+		return nil, errors.New("reading Secrets is not supported")
+	case g.ClientForAttributes != nil:
+		return g.ClientForAttributes(attrs)
+	case g.Client != nil:
+		return g.Client, nil
+	default:
+		return nil, errors.New("reading Secrets is not supported")
+	}
+}
+
+// value returns the value of the given data key of the named Secret.
+func value(client corev1client.SecretsGetter, namespace, name, key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	secret, err := client.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Secret %s/%s: %w", namespace, name, err)
+	}
+
+	value, ok := secret.Data[key]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("no value for key %q in Secret %s/%s", key, namespace, name)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	passwordKey   = "csi.cert-manager.io/privatekey-password"
+	secretNameKey = "csi.cert-manager.io/privatekey-password-secret-name"
+	secretKeyKey  = "csi.cert-manager.io/privatekey-password-secret-key"
+)
+
+func Test_Password(t *testing.T) {
+	client := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "key-password", Namespace: "my-namespace"},
+		Data: map[string][]byte{
+			"password": []byte("from-secret"),
+			"empty":    {},
+		},
+	})
+
+	tests := map[string]struct {
+		getter      *Getter
+		attrs       map[string]string
+		expPassword []byte
+		expErr      bool
+	}{
+		"no attributes should return no password": {
+			getter:      &Getter{Client: client.CoreV1()},
+			attrs:       map[string]string{},
+			expPassword: nil,
+		},
+		"a password attribute should be returned": {
+			getter: &Getter{Client: client.CoreV1()},
+			attrs: map[string]string{
+				passwordKey: "from-attribute",
+			},
+			expPassword: []byte("from-attribute"),
+		},
+		"a password attribute should be returned by a nil Getter": {
+			getter: nil,
+			attrs: map[string]string{
+				passwordKey: "from-attribute",
+			},
+			expPassword: []byte("from-attribute"),
+		},
+		"a Secret in the pod's namespace should be read": {
+			getter: &Getter{Client: client.CoreV1()},
+			attrs: map[string]string{
+				"csi.storage.k8s.io/pod.namespace": "my-namespace",
+				secretNameKey:                      "key-password",
+				secretKeyKey:                       "password",
+			},
+			expPassword: []byte("from-secret"),
+		},
+		"a Secret in another namespace should not be found": {
+			getter: &Getter{Client: client.CoreV1()},
+			attrs: map[string]string{
+				"csi.storage.k8s.io/pod.namespace": "other-namespace",
+				secretNameKey:                      "key-password",
+				secretKeyKey:                       "password",
+			},
+			expErr: true,
+		},
+		"a missing key should error": {
+			getter: &Getter{Client: client.CoreV1()},
+			attrs: map[string]string{
+				"csi.storage.k8s.io/pod.namespace": "my-namespace",
+				secretNameKey:                      "key-password",
+				secretKeyKey:                       "other",
+			},
+			expErr: true,
+		},
+		"an empty value should error": {
+			getter: &Getter{Client: client.CoreV1()},
+			attrs: map[string]string{
+				"csi.storage.k8s.io/pod.namespace": "my-namespace",
+				secretNameKey:                      "key-password",
+				secretKeyKey:                       "empty",
+			},
+			expErr: true,
+		},
+		"a Secret reference with a nil Getter should error": {
+			getter: nil,
+			attrs: map[string]string{
+				"csi.storage.k8s.io/pod.namespace": "my-namespace",
+				secretNameKey:                      "key-password",
+				secretKeyKey:                       "password",
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			password, err := test.getter.Password(test.attrs, passwordKey, secretNameKey, secretKeyKey)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expPassword, password)
+		})
+	}
+}
+
+func Test_ClientForAttributesTokenRequestEmptyAud(t *testing.T) {
+	clientFor := ClientForAttributesTokenRequestEmptyAud(&rest.Config{Host: "https://example.com"})
+
+	tests := map[string]struct {
+		attrs  map[string]string
+		expErr bool
+	}{
+		"no tokens should error": {
+			attrs:  map[string]string{},
+			expErr: true,
+		},
+		"tokens without the empty audience should error": {
+			attrs: map[string]string{
+				"csi.storage.k8s.io/serviceAccount.tokens": `{"other":{"token":"token"}}`,
+			},
+			expErr: true,
+		},
+		"a token with the empty audience should return a client": {
+			attrs: map[string]string{
+				"csi.storage.k8s.io/serviceAccount.tokens": `{"":{"token":"token"}}`,
+			},
+			expErr: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, err := clientFor(test.attrs)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expErr, client == nil)
+		})
+	}
+}
+
+func Test_PasswordClientForAttributes(t *testing.T) {
+	client := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "key-password", Namespace: "my-namespace"},
+		Data:       map[string][]byte{"password": []byte("from-pod-client")},
+	})
+
+	var gotAttrs map[string]string
+	getter := &Getter{
+		Client: fake.NewClientset().CoreV1(),
+		ClientForAttributes: func(attrs map[string]string) (corev1client.SecretsGetter, error) {
+			gotAttrs = attrs
+			return client.CoreV1(), nil
+		},
+	}
+
+	attrs := map[string]string{
+		"csi.storage.k8s.io/pod.namespace": "my-namespace",
+		secretNameKey:                      "key-password",
+		secretKeyKey:                       "password",
+	}
+	password, err := getter.Password(attrs, passwordKey, secretNameKey, secretKeyKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("from-pod-client"), password)
+	assert.Equal(t, attrs, gotAttrs)
+}
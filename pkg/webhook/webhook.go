@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook implements a validating admission webhook which rejects
+// Pods whose csi-driver volumes have invalid attributes, so that mistakes
+// surface at admission rather than at NodePublishVolume time on the node.
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/cert-manager/csi-driver/pkg/apis/defaults"
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+)
+
+// Validator is an admission.Handler which validates the attributes of the
+// inline CSI volumes of Pods that use the driver.
+type Validator struct {
+	// driverName is the name of the CSI driver whose volumes are validated.
+	driverName string
+
+	// clusterDefaults are the cluster-wide default attributes, and opts the
+	// validation options, as given to the driver.
+	clusterDefaults map[string]string
+	opts            validation.Options
+
+	decoder admission.Decoder
+}
+
+var _ admission.Handler = &Validator{}
+
+// NewValidator returns a Validator for volumes of the given driver name,
+// defaulted with the given cluster-wide default attributes and validated with
+// the given options.
+func NewValidator(driverName string, clusterDefaults map[string]string, opts validation.Options) *Validator {
+	return &Validator{
+		driverName:      driverName,
+		clusterDefaults: clusterDefaults,
+		opts:            opts,
+		decoder:         admission.NewDecoder(scheme.Scheme),
+	}
+}
+
+// Handle denies Pod CREATE requests where any of the Pod's volumes for the
+// driver fail validation after defaulting. All other requests are allowed.
+func (v *Validator) Handle(_ context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create ||
+		req.Kind.Group != "" || req.Kind.Kind != "Pod" {
+		return admission.Allowed("")
+	}
+
+	var pod corev1.Pod
+	if err := v.decoder.Decode(req, &pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if errs := v.validatePod(&pod); len(errs) > 0 {
+		return admission.Denied(strings.Join(errs, "; "))
+	}
+
+	return admission.Allowed("")
+}
+
+// validatePod returns an error message for each of the Pod's volumes for
+// the driver which are invalid.
+func (v *Validator) validatePod(pod *corev1.Pod) []string {
+	var errs []string
+	for _, volume := range pod.Spec.Volumes {
+		if volume.CSI == nil || volume.CSI.Driver != v.driverName {
+			continue
+		}
+
+		attrs, err := defaults.SetDefaultAttributes(volume.CSI.VolumeAttributes, v.clusterDefaults)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("volume %q: %s", volume.Name, err))
+			continue
+		}
+
+		if el := validation.ValidateAttributes(attrs, v.opts); len(el) > 0 {
+			errs = append(errs, fmt.Sprintf("volume %q: %s", volume.Name, el.ToAggregate()))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/cert-manager/csi-driver/pkg/apis/validation"
+)
+
+func csiVolume(name, driver string, attrs map[string]string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			CSI: &corev1.CSIVolumeSource{Driver: driver, VolumeAttributes: attrs},
+		},
+	}
+}
+
+func Test_Handle(t *testing.T) {
+	podKind := metav1.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+	tests := map[string]struct {
+		operation admissionv1.Operation
+		kind      metav1.GroupVersionKind
+		volumes   []corev1.Volume
+
+		expAllowed bool
+		expMessage string
+	}{
+		"a pod with no volumes should be allowed": {
+			operation:  admissionv1.Create,
+			kind:       podKind,
+			expAllowed: true,
+		},
+		"a pod with a valid volume should be allowed": {
+			operation: admissionv1.Create,
+			kind:      podKind,
+			volumes: []corev1.Volume{
+				csiVolume("tls", "csi.cert-manager.io", map[string]string{
+					"csi.cert-manager.io/issuer-name": "ca-issuer",
+					"csi.cert-manager.io/dns-names":   "${POD_NAME}.example.com",
+				}),
+			},
+			expAllowed: true,
+		},
+		"a pod with an invalid volume of another driver should be allowed": {
+			operation: admissionv1.Create,
+			kind:      podKind,
+			volumes: []corev1.Volume{
+				csiVolume("other", "other.csi.example.com", nil),
+			},
+			expAllowed: true,
+		},
+		"a pod with an invalid volume should be denied with the field errors": {
+			operation: admissionv1.Create,
+			kind:      podKind,
+			volumes: []corev1.Volume{
+				csiVolume("tls", "csi.cert-manager.io", map[string]string{
+					"csi.cert-manager.io/issuer-name": "ca-issuer",
+				}),
+				csiVolume("bad", "csi.cert-manager.io", map[string]string{
+					"csi.cert-manager.io/is-ca": "maybe",
+				}),
+			},
+			expAllowed: false,
+			expMessage: `volume "bad": [volumeAttributes.csi.cert-manager.io/issuer-name: Required value: issuer-name is a required field, volumeAttributes.csi.cert-manager.io/is-ca: Invalid value: "maybe": may only accept values of "true" or "false"]`,
+		},
+		"errors from multiple volumes should all be reported": {
+			operation: admissionv1.Create,
+			kind:      podKind,
+			volumes: []corev1.Volume{
+				csiVolume("a", "csi.cert-manager.io", nil),
+				csiVolume("b", "csi.cert-manager.io", nil),
+			},
+			expAllowed: false,
+			expMessage: `volume "a": volumeAttributes.csi.cert-manager.io/issuer-name: Required value: issuer-name is a required field; volume "b": volumeAttributes.csi.cert-manager.io/issuer-name: Required value: issuer-name is a required field`,
+		},
+		"an invalid pod UPDATE should be allowed": {
+			operation: admissionv1.Update,
+			kind:      podKind,
+			volumes: []corev1.Volume{
+				csiVolume("bad", "csi.cert-manager.io", nil),
+			},
+			expAllowed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-pod", Namespace: "sandbox"},
+				Spec:       corev1.PodSpec{Volumes: test.volumes},
+			}
+			raw, err := json.Marshal(pod)
+			require.NoError(t, err)
+
+			resp := NewValidator("csi.cert-manager.io", nil, validation.Options{}).Handle(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: test.operation,
+					Kind:      test.kind,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+
+			assert.Equal(t, test.expAllowed, resp.Allowed)
+			if !test.expAllowed {
+				require.NotNil(t, resp.Result)
+				assert.Equal(t, test.expMessage, resp.Result.Message)
+			}
+		})
+	}
+}